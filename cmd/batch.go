@@ -0,0 +1,25 @@
+package cmd
+
+// forEachBatch drains a channel and calls fn with batches of at most batchSize items.
+// It stops at the first error returned by fn.
+func forEachBatch[T any](ch <-chan T, batchSize int, fn func([]T) error) error {
+	batch := make([]T, 0, batchSize)
+	for item := range ch {
+		batch = append(batch, item)
+		if len(batch) < batchSize {
+			continue
+		}
+
+		if err := fn(batch); err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+	}
+
+	if len(batch) > 0 {
+		return fn(batch)
+	}
+
+	return nil
+}
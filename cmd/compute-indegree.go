@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+)
+
+// ComputeInDegreeHandler stores the number of dependents of each module version in dependentsCount,
+// and the number of dependents of all the versions of its module in moduleDependentsCount, to rank the modules.
+func ComputeInDegreeHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+		distinctBy := command.Lookup[string](flagSet, "distinct-by")
+
+		var byVersion bool
+		switch distinctBy {
+		case "name":
+			byVersion = false
+
+		case "name-version":
+			byVersion = true
+
+		default:
+			slog.Error("invalid \"distinct-by\", expected name or name-version", slog.String("distinct-by", distinctBy))
+			return 1
+		}
+
+		chNames, chErr := iterModuleNames(ctx, driver)
+
+		progress := progressbar.Default(-1, "computing dependents count")
+
+		var nbUpdated int64
+		if err := forEachBatch(chNames, batchSize, func(names []string) error {
+			slog.Debug("updating dependents count", slog.Int("count", len(names)))
+			result, err := executeQuery(ctx, driver, `
+				UNWIND $names AS name
+				MATCH (m:Module {name: name})
+				OPTIONAL MATCH (m)<-[:DEPENDS_ON]-(dependent:Module)
+				WITH m, count(DISTINCT CASE WHEN $byVersion THEN dependent.name + '@' + dependent.version ELSE dependent.name END) AS dependentsCount
+				SET m.dependentsCount = dependentsCount
+				WITH DISTINCT m.name AS name
+				CALL {
+					WITH name
+					MATCH (:Module {name: name})<-[:DEPENDS_ON]-(dependent:Module)
+					RETURN count(DISTINCT CASE WHEN $byVersion THEN dependent.name + '@' + dependent.version ELSE dependent.name END) AS moduleDependentsCount
+				}
+				MATCH (m:Module {name: name})
+				SET m.moduleDependentsCount = moduleDependentsCount
+				RETURN count(m) AS updated
+			`, map[string]any{
+				"names":     names,
+				"byVersion": byVersion,
+			}, neo4j.ExecuteQueryWithDatabase(""))
+			if err != nil {
+				return fmt.Errorf("failed to update dependents count: %w", err)
+			}
+
+			updated, _, err := neo4j.GetRecordValue[int64](result.Records[0], "updated")
+			if err != nil {
+				return fmt.Errorf("failed to read updated count: %w", err)
+			}
+
+			nbUpdated += updated
+			if err := progress.Add(len(names)); err != nil {
+				slog.Error("failed to update progress bar", slog.Any("error", err))
+			}
+
+			return nil
+		}); err != nil {
+			slog.Error("failed to compute dependents count", slog.Any("error", err))
+			return 1
+		}
+
+		if err := <-chErr; err != nil {
+			slog.Error("failed to list module names", slog.Any("error", err))
+			return 1
+		}
+
+		_ = progress.Finish()
+		fmt.Printf("%d module nodes updated\n", nbUpdated)
+
+		return 0
+	}
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/semver"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// DependenciesHandler lists the direct dependencies of a module, optionally collapsing the versions of each dependency.
+func DependenciesHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		modulePath := command.Lookup[string](flagSet, "module")
+		version := command.Lookup[string](flagSet, "module-version")
+		collapseVersions := command.Lookup[bool](flagSet, "collapse-versions")
+		format := command.Lookup[string](flagSet, "format")
+
+		if modulePath == "" {
+			slog.Error("\"module\" is required")
+			return 1
+		}
+
+		slog.Debug("listing dependencies", slog.String("module", modulePath), slog.String("version", version))
+		result, err := executeQuery(ctx, driver, `
+			MATCH (dependent:Module {name: $name})-[:DEPENDS_ON]->(dependency:Module)
+			WHERE $version = "" OR dependent.version = $version
+			RETURN dependent.version AS dependentVersion, dependency.name AS dependency, dependency.version AS dependencyVersion
+			ORDER BY dependentVersion, dependency, dependencyVersion
+		`, map[string]any{
+			"name":    modulePath,
+			"version": version,
+		}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+		if err != nil {
+			slog.Error("failed to list dependencies", slog.Any("error", err))
+			return 1
+		}
+
+		var rows [][]string
+		for _, record := range result.Records {
+			dependentVersion, _, _ := neo4j.GetRecordValue[string](record, "dependentVersion")
+			dependency, _, _ := neo4j.GetRecordValue[string](record, "dependency")
+			dependencyVersion, _, _ := neo4j.GetRecordValue[string](record, "dependencyVersion")
+
+			rows = append(rows, []string{modulePath, dependentVersion, dependency, dependencyVersion})
+		}
+
+		if collapseVersions {
+			rows = collapseDependencyVersions(rows)
+		}
+
+		if err := writeRows(os.Stdout, format, []string{"dependent", "dependentVersion", "dependency", "dependencyVersion"}, rows); err != nil {
+			slog.Error("failed to write dependencies", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
+
+// collapseDependencyVersions keeps a single row per dependency path, with its highest version across all the listed
+// dependent versions, along with the dependent version requiring it.
+func collapseDependencyVersions(rows [][]string) [][]string {
+	collapsed := make([][]string, 0, len(rows))
+	indexes := map[string]int{}
+	for _, row := range rows {
+		i, ok := indexes[row[2]]
+		if !ok {
+			indexes[row[2]] = len(collapsed)
+			collapsed = append(collapsed, slices.Clone(row))
+			continue
+		}
+
+		if highestVersion(collapsed[i][3], row[3]) != collapsed[i][3] {
+			collapsed[i][1], collapsed[i][3] = row[1], row[3]
+		}
+	}
+
+	slices.SortFunc(collapsed, func(a, b []string) int {
+		return strings.Compare(a[2], b[2])
+	})
+
+	return collapsed
+}
+
+// highestVersion returns the version with the highest precedence, the invalid versions having the lowest.
+func highestVersion(a, b string) string {
+	parsedA, errA := semver.Parse(a)
+	parsedB, errB := semver.Parse(b)
+
+	switch {
+	case errA != nil:
+		return b
+	case errB != nil:
+		return a
+	case semver.Compare(parsedA, parsedB) < 0:
+		return b
+	}
+
+	return a
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCollapseDependencyVersions(t *testing.T) {
+	rows := [][]string{
+		{"example.com/m", "v1.0.0", "example.com/a", "v1.2.0"},
+		{"example.com/m", "v1.0.0", "example.com/b", "v0.1.0"},
+		{"example.com/m", "v1.1.0", "example.com/a", "v1.10.0"},
+		{"example.com/m", "v1.1.0", "example.com/b", "v0.1.0"},
+		{"example.com/m", "v1.2.0", "example.com/a", "v1.9.0"},
+		{"example.com/m", "v1.2.0", "example.com/c", "v2.0.0+incompatible"},
+	}
+
+	expected := [][]string{
+		{"example.com/m", "v1.1.0", "example.com/a", "v1.10.0"},
+		{"example.com/m", "v1.0.0", "example.com/b", "v0.1.0"},
+		{"example.com/m", "v1.2.0", "example.com/c", "v2.0.0+incompatible"},
+	}
+
+	actual := collapseDependencyVersions(rows)
+	if !slices.EqualFunc(actual, expected, slices.Equal[[]string]) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
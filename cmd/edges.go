@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+type edge struct {
+	Dependent         string `json:"dependent"`
+	DependentVersion  string `json:"dependentVersion"`
+	Dependency        string `json:"dependency"`
+	DependencyVersion string `json:"dependencyVersion"`
+	Direct            bool   `json:"direct"`
+	Tool              bool   `json:"tool"`
+}
+
+// edgeWriter writes the dependency edges as JSON lines, safely from concurrent workers.
+type edgeWriter struct {
+	mx      sync.Mutex
+	encoder *json.Encoder
+}
+
+func newEdgeWriter(w io.Writer) *edgeWriter {
+	return &edgeWriter{encoder: json.NewEncoder(w)}
+}
+
+func (w *edgeWriter) write(dependent moduleNode, dependencies []moduleDependency) error {
+	w.mx.Lock()
+	defer w.mx.Unlock()
+
+	for _, dependency := range dependencies {
+		if err := w.encoder.Encode(edge{
+			Dependent:         dependent.Path,
+			DependentVersion:  dependent.Version.Version,
+			Dependency:        dependency.Path,
+			DependencyVersion: dependency.Version.Version,
+			Direct:            dependency.direct,
+			Tool:              dependency.tool,
+		}); err != nil {
+			return fmt.Errorf("failed to write edge: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/goproxy"
+	"github.com/Thiht/go-stats/semver"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/sync/errgroup"
+)
+
+type latestResult struct {
+	name string
+	info goproxy.ModuleInfo
+}
+
+// EnrichLatestHandler stores the latest version of each module on all its nodes.
+func EnrichLatestHandler(driver neo4j.DriverWithContext, goProxyClient goproxy.Client) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		parallel := command.Lookup[int](flagSet, "parallel")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+		failedFile := command.Lookup[string](flagSet, "failed-file")
+		sampleRate := command.Lookup[float64](flagSet, "sample")
+		sampleSeed := command.Lookup[int64](flagSet, "sample-seed")
+		retryFile := command.Lookup[string](flagSet, "retry-file")
+		latestMaxAge := command.Lookup[time.Duration](flagSet, "latest-max-age")
+
+		sample, err := newSampler(sampleRate, sampleSeed)
+		if err != nil {
+			slog.Error("invalid \"sample\"", slog.Any("error", err))
+			return 1
+		}
+
+		var (
+			chNames <-chan string
+			chErr   <-chan error
+		)
+		if retryFile != "" {
+			chNames, chErr = iterFileModuleNames(ctx, retryFile)
+		} else {
+			chNames, chErr = iterModuleNames(ctx, driver)
+		}
+
+		progress := progressbar.Default(-1, "enriching latest versions")
+		failures := newFailureSummary()
+
+		chResults := make(chan latestResult, batchSize)
+		go func() {
+			defer close(chResults)
+
+			var g errgroup.Group
+			g.SetLimit(parallel)
+
+			for name := range chNames {
+				if !sample.keep(name) {
+					continue
+				}
+
+				g.Go(func() error {
+					info, err := getLatestInfo(ctx, goProxyClient, name, latestMaxAge)
+					if err != nil {
+						slog.Warn("failed to get latest module info", slog.String("module", name), slog.Any("error", err))
+						failures.add(classifyFailure(err), name)
+						return nil
+					}
+
+					if !semver.Valid(info.Version) {
+						slog.Warn("invalid latest version", slog.String("module", name), slog.String("version", info.Version))
+						failures.add(failureInvalidVersion, name)
+						return nil
+					}
+
+					chResults <- latestResult{name: name, info: info}
+					return nil
+				})
+			}
+
+			_ = g.Wait()
+		}()
+
+		var nbSucceeded atomic.Int64
+		_ = forEachBatch(chResults, batchSize, func(results []latestResult) error {
+			modules := make([]map[string]any, 0, len(results))
+			for _, result := range results {
+				modules = append(modules, map[string]any{
+					"name":    result.name,
+					"version": result.info.Version,
+					"time":    result.info.Time,
+				})
+			}
+
+			slog.Debug("updating latest versions", slog.Int("count", len(modules)))
+			if _, err := executeQuery(ctx, driver, `
+				UNWIND $modules AS module
+				MATCH (m:Module {name: module.name})
+				SET m.latestVersion = module.version, m.latestTime = module.time
+			`, map[string]any{
+				"modules": modules,
+			}, neo4j.ExecuteQueryWithDatabase("")); err != nil {
+				slog.Warn("failed to update latest versions", slog.Int("count", len(modules)), slog.Any("error", err))
+				for _, result := range results {
+					failures.add(failureWriteError, result.name)
+				}
+			} else {
+				nbSucceeded.Add(int64(len(results)))
+			}
+
+			if err := progress.Add(len(results)); err != nil {
+				slog.Error("failed to update progress bar", slog.Any("error", err))
+			}
+
+			return nil
+		})
+
+		if err := <-chErr; err != nil {
+			slog.Error("failed to list modules to enrich", slog.Any("error", err))
+			return 1
+		}
+
+		_ = progress.Finish()
+		failures.print(os.Stdout, "enriched", nbSucceeded.Load())
+
+		if failedFile != "" {
+			if err := failures.writeModules(failedFile); err != nil {
+				slog.Error("failed to write failed modules", slog.String("file", failedFile), slog.Any("error", err))
+				return 1
+			}
+		}
+
+		return 0
+	}
+}
+
+// getLatestInfo gets the latest module info from the proxy cache, and falls back to the origin if it's not cached.
+// If maxAge is positive, the origin is also queried when the cached latest version was released more than maxAge ago,
+// in which case the cached info is still returned if the origin fails. The proxy doesn't tell when it cached the version,
+// so the release time is the only age available: a module without recent releases is always queried from the origin.
+func getLatestInfo(ctx context.Context, goProxyClient goproxy.Client, modulePath string, maxAge time.Duration) (goproxy.ModuleInfo, error) {
+	info, err := goProxyClient.GetModuleLatestInfo(ctx, modulePath, true)
+	if err == nil {
+		if maxAge <= 0 || time.Since(info.Time) <= maxAge {
+			return info, nil
+		}
+
+		liveInfo, err := goProxyClient.GetModuleLatestInfo(ctx, modulePath, false)
+		if err != nil {
+			slog.Warn("failed to refresh stale latest module info, using the cached one", slog.String("module", modulePath), slog.Time("releaseTime", info.Time), slog.Any("error", err))
+			return info, nil
+		}
+
+		return liveInfo, nil
+	}
+
+	if !errors.Is(err, goproxy.ErrModuleNotFound) {
+		return goproxy.ModuleInfo{}, fmt.Errorf("failed to get cached latest module info: %w", err)
+	}
+
+	info, err = goProxyClient.GetModuleLatestInfo(ctx, modulePath, false)
+	if err != nil {
+		return goproxy.ModuleInfo{}, fmt.Errorf("failed to get latest module info: %w", err)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/goproxy"
+	"github.com/Thiht/go-stats/semver"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/errgroup"
+)
+
+type majorVersionsResult struct {
+	names         []string
+	majorVersions int
+}
+
+// EnrichMajorVersionsHandler stores on each module node the number of distinct major versions published for its base module,
+// so that github.com/x/y and github.com/x/y/v2 both count the majors of github.com/x/y.
+func EnrichMajorVersionsHandler(driver neo4j.DriverWithContext, goProxyClient goproxy.Client) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		parallel := command.Lookup[int](flagSet, "parallel")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+		failedFile := command.Lookup[string](flagSet, "failed-file")
+		sampleRate := command.Lookup[float64](flagSet, "sample")
+		sampleSeed := command.Lookup[int64](flagSet, "sample-seed")
+		retryFile := command.Lookup[string](flagSet, "retry-file")
+
+		sample, err := newSampler(sampleRate, sampleSeed)
+		if err != nil {
+			slog.Error("invalid \"sample\"", slog.Any("error", err))
+			return 1
+		}
+
+		// The retry file lists base paths, so the module paths of the graph are still listed to enrich their majors too
+		var retryBasePaths map[string]struct{}
+		if retryFile != "" {
+			chRetryNames, chRetryErr := iterFileModuleNames(ctx, retryFile)
+
+			retryBasePaths = map[string]struct{}{}
+			for name := range chRetryNames {
+				retryBasePaths[name] = struct{}{}
+			}
+
+			if err := <-chRetryErr; err != nil {
+				slog.Error("failed to read modules to retry", slog.String("file", retryFile), slog.Any("error", err))
+				return 1
+			}
+		}
+
+		chNames, chErr := iterModuleNames(ctx, driver)
+
+		// The module paths of the graph are grouped by base path, to list all their majors at once
+		basePaths := map[string][]string{}
+		for name := range chNames {
+			prefix, _, ok := module.SplitPathVersion(name)
+			if !ok {
+				prefix = name
+			}
+
+			if retryBasePaths != nil {
+				if _, ok := retryBasePaths[prefix]; !ok {
+					continue
+				}
+			}
+
+			if !sample.keep(prefix) {
+				continue
+			}
+
+			basePaths[prefix] = append(basePaths[prefix], name)
+		}
+
+		if err := <-chErr; err != nil {
+			slog.Error("failed to list modules to enrich", slog.Any("error", err))
+			return 1
+		}
+
+		progress := progressbar.Default(int64(len(basePaths)), "enriching major versions")
+		failures := newFailureSummary()
+
+		chResults := make(chan majorVersionsResult, batchSize)
+		go func() {
+			defer close(chResults)
+
+			var g errgroup.Group
+			g.SetLimit(parallel)
+
+			for basePath, names := range basePaths {
+				g.Go(func() error {
+					majorVersions, err := countMajorVersions(ctx, goProxyClient, basePath, names)
+					if err != nil {
+						slog.Warn("failed to count major versions", slog.String("module", basePath), slog.Any("error", err))
+						failures.add(classifyFailure(err), basePath)
+						return nil
+					}
+
+					chResults <- majorVersionsResult{names: names, majorVersions: majorVersions}
+					return nil
+				})
+			}
+
+			_ = g.Wait()
+		}()
+
+		var nbSucceeded atomic.Int64
+		_ = forEachBatch(chResults, batchSize, func(results []majorVersionsResult) error {
+			modules := make([]map[string]any, 0, len(results))
+			for _, result := range results {
+				for _, name := range result.names {
+					modules = append(modules, map[string]any{
+						"name":          name,
+						"majorVersions": result.majorVersions,
+					})
+				}
+			}
+
+			slog.Debug("updating major versions", slog.Int("count", len(modules)))
+			if _, err := executeQuery(ctx, driver, `
+				UNWIND $modules AS module
+				MATCH (m:Module {name: module.name})
+				SET m.majorVersions = module.majorVersions
+			`, map[string]any{
+				"modules": modules,
+			}, neo4j.ExecuteQueryWithDatabase("")); err != nil {
+				slog.Warn("failed to update major versions", slog.Int("count", len(modules)), slog.Any("error", err))
+				for _, result := range results {
+					failures.add(failureWriteError, result.names[0])
+				}
+			} else {
+				nbSucceeded.Add(int64(len(results)))
+			}
+
+			if err := progress.Add(len(results)); err != nil {
+				slog.Error("failed to update progress bar", slog.Any("error", err))
+			}
+
+			return nil
+		})
+
+		_ = progress.Finish()
+		failures.print(os.Stdout, "enriched", nbSucceeded.Load())
+
+		if failedFile != "" {
+			if err := failures.writeModules(failedFile); err != nil {
+				slog.Error("failed to write failed modules", slog.String("file", failedFile), slog.Any("error", err))
+				return 1
+			}
+		}
+
+		return 0
+	}
+}
+
+// countMajorVersions counts the distinct majors of the versions published for a base module.
+// The versions are listed for the base path and the major paths known from the graph, then for the next
+// /vN paths until one doesn't exist, since the graph doesn't necessarily contain the latest majors.
+func countMajorVersions(ctx context.Context, goProxyClient goproxy.Client, basePath string, names []string) (int, error) {
+	majors := map[uint64]struct{}{}
+
+	addVersions := func(modulePath string) (bool, error) {
+		versions, err := listVersions(ctx, goProxyClient, modulePath)
+		if err != nil {
+			if errors.Is(err, goproxy.ErrModuleNotFound) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		for _, version := range versions {
+			parsed, err := semver.Parse(version)
+			if err != nil {
+				continue
+			}
+
+			majors[parsed.Major] = struct{}{}
+		}
+
+		return len(versions) > 0, nil
+	}
+
+	// gopkg.in paths always have a major suffix, so there is neither a base module nor /vN paths to probe
+	isGopkgIn := strings.HasPrefix(basePath, "gopkg.in/")
+
+	paths := map[string]struct{}{}
+	if !isGopkgIn {
+		paths[basePath] = struct{}{}
+	}
+	for _, name := range names {
+		paths[name] = struct{}{}
+	}
+
+	var highestMajor uint64 = 1
+	for path := range paths {
+		if _, err := addVersions(path); err != nil {
+			return 0, fmt.Errorf("failed to list versions of %s: %w", path, err)
+		}
+
+		if _, pathMajor, ok := module.SplitPathVersion(path); ok && strings.HasPrefix(pathMajor, "/v") {
+			if major, err := strconv.ParseUint(pathMajor[2:], 10, 64); err == nil {
+				highestMajor = max(highestMajor, major)
+			}
+		}
+	}
+
+	if !isGopkgIn {
+		for major := highestMajor + 1; ; major++ {
+			path := basePath + "/v" + strconv.FormatUint(major, 10)
+			found, err := addVersions(path)
+			if err != nil {
+				return 0, fmt.Errorf("failed to list versions of %s: %w", path, err)
+			}
+
+			if !found {
+				break
+			}
+		}
+	}
+
+	return len(majors), nil
+}
+
+// listVersions lists the versions of a module from the proxy cache, and falls back to the origin if it's not cached.
+func listVersions(ctx context.Context, goProxyClient goproxy.Client, modulePath string) ([]string, error) {
+	versions, err := goProxyClient.ListModuleVersions(ctx, modulePath, true)
+	if err == nil {
+		return versions, nil
+	}
+
+	if !errors.Is(err, goproxy.ErrModuleNotFound) {
+		return nil, fmt.Errorf("failed to list cached module versions: %w", err)
+	}
+
+	versions, err = goProxyClient.ListModuleVersions(ctx, modulePath, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list module versions: %w", err)
+	}
+
+	return versions, nil
+}
@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/goproxy"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/errgroup"
+)
+
+type originResult struct {
+	module.Version
+	info goproxy.ModuleInfo
+}
+
+// EnrichOriginHandler stores the VCS origin of the module versions that don't have one yet, to backfill an existing graph
+// without processing the modules again. The versions whose proxy info has no origin get empty properties, so that they
+// aren't fetched again by the next runs.
+func EnrichOriginHandler(driver neo4j.DriverWithContext, goProxyClient goproxy.Client) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		parallel := command.Lookup[int](flagSet, "parallel")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+		failedFile := command.Lookup[string](flagSet, "failed-file")
+		retryFile := command.Lookup[string](flagSet, "retry-file")
+
+		var (
+			chModules <-chan module.Version
+			chErr     <-chan error
+		)
+		if retryFile != "" {
+			chModules, chErr = iterFileModuleVersions(ctx, retryFile)
+		} else {
+			chModules, chErr = iterModuleVersionsWithoutOrigin(ctx, driver)
+		}
+
+		progress := progressbar.Default(-1, "enriching origins")
+		failures := newFailureSummary()
+
+		chResults := make(chan originResult, batchSize)
+		go func() {
+			defer close(chResults)
+
+			var g errgroup.Group
+			g.SetLimit(parallel)
+
+			for m := range chModules {
+				g.Go(func() error {
+					info, err := getModuleInfo(ctx, goProxyClient, m)
+					if err != nil {
+						slog.Warn("failed to get module info", slog.Any("module", m), slog.Any("error", err))
+						// The failed versions are written in the seed format, so that the failed file can be used as a retry file
+						failures.add(classifyFailure(err), m.Path+" "+m.Version)
+						return nil
+					}
+
+					chResults <- originResult{Version: m, info: info}
+					return nil
+				})
+			}
+
+			_ = g.Wait()
+		}()
+
+		var nbSucceeded atomic.Int64
+		_ = forEachBatch(chResults, batchSize, func(results []originResult) error {
+			modules := make([]map[string]any, 0, len(results))
+			for _, result := range results {
+				modules = append(modules, map[string]any{
+					"name":    result.Path,
+					"version": result.Version.Version,
+					"vcs":     result.info.Origin.VCS,
+					"vcsURL":  result.info.Origin.URL,
+					"vcsHash": result.info.Origin.Hash,
+				})
+			}
+
+			slog.Debug("updating origins", slog.Int("count", len(modules)))
+			if _, err := executeQuery(ctx, driver, `
+				UNWIND $modules AS module
+				MATCH (m:Module {name: module.name, version: module.version})
+				SET m.vcs = module.vcs, m.vcsURL = module.vcsURL, m.vcsHash = module.vcsHash
+			`, map[string]any{
+				"modules": modules,
+			}, neo4j.ExecuteQueryWithDatabase("")); err != nil {
+				slog.Warn("failed to update origins", slog.Int("count", len(modules)), slog.Any("error", err))
+				for _, result := range results {
+					failures.add(failureWriteError, result.Path+" "+result.Version.Version)
+				}
+			} else {
+				nbSucceeded.Add(int64(len(results)))
+			}
+
+			if err := progress.Add(len(results)); err != nil {
+				slog.Error("failed to update progress bar", slog.Any("error", err))
+			}
+
+			return nil
+		})
+
+		if err := <-chErr; err != nil {
+			slog.Error("failed to list modules to enrich", slog.Any("error", err))
+			return 1
+		}
+
+		_ = progress.Finish()
+		failures.print(os.Stdout, "enriched", nbSucceeded.Load())
+
+		if failedFile != "" {
+			if err := failures.writeModules(failedFile); err != nil {
+				slog.Error("failed to write failed modules", slog.String("file", failedFile), slog.Any("error", err))
+				return 1
+			}
+		}
+
+		return 0
+	}
+}
+
+// getModuleInfo gets the info of a module version from the proxy cache, and falls back to the origin if it's not cached.
+func getModuleInfo(ctx context.Context, goProxyClient goproxy.Client, m module.Version) (goproxy.ModuleInfo, error) {
+	info, err := goProxyClient.GetModuleInfo(ctx, m.Path, m.Version, true)
+	if err == nil {
+		return info, nil
+	}
+
+	if !errors.Is(err, goproxy.ErrModuleNotFound) {
+		return goproxy.ModuleInfo{}, fmt.Errorf("failed to get cached module info: %w", err)
+	}
+
+	info, err = goProxyClient.GetModuleInfo(ctx, m.Path, m.Version, false)
+	if err != nil {
+		return goproxy.ModuleInfo{}, fmt.Errorf("failed to get module info: %w", err)
+	}
+
+	return info, nil
+}
+
+// iterModuleVersionsWithoutOrigin streams the module versions of the graph without vcs property.
+// It has the same contract as iterModuleNames.
+func iterModuleVersionsWithoutOrigin(ctx context.Context, driver neo4j.DriverWithContext) (<-chan module.Version, <-chan error) {
+	chModules := make(chan module.Version, 1_000)
+	chErr := make(chan error, 1)
+
+	go func() {
+		defer close(chErr)
+		defer close(chModules)
+
+		session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "", AccessMode: neo4j.AccessModeRead})
+		defer session.Close(ctx)
+
+		slog.Debug("listing module versions without origin")
+		result, err := session.Run(ctx, withGraphSchema("MATCH (m:Module) WHERE m.vcs IS NULL RETURN m.name AS name, m.version AS version"), nil)
+		if err != nil {
+			chErr <- fmt.Errorf("failed to list module versions: %w", err)
+			return
+		}
+
+		for result.Next(ctx) {
+			name, _, err := neo4j.GetRecordValue[string](result.Record(), "name")
+			if err != nil {
+				chErr <- fmt.Errorf("failed to read module name: %w", err)
+				return
+			}
+
+			version, _, err := neo4j.GetRecordValue[string](result.Record(), "version")
+			if err != nil {
+				chErr <- fmt.Errorf("failed to read module version: %w", err)
+				return
+			}
+
+			select {
+			case chModules <- module.Version{Path: name, Version: version}:
+			case <-ctx.Done():
+				chErr <- ctx.Err()
+				return
+			}
+		}
+		if err := result.Err(); err != nil {
+			chErr <- fmt.Errorf("failed to list module versions: %w", err)
+		}
+	}()
+
+	return chModules, chErr
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/Thiht/go-stats/goproxy"
+)
+
+type failureCategory string
+
+const (
+	failureNotFound       failureCategory = "not-found"
+	failureTimeout        failureCategory = "timeout"
+	failureCanceled       failureCategory = "canceled"
+	failureInvalidVersion failureCategory = "invalid-version"
+	failureWriteError     failureCategory = "write-error"
+	failureOther          failureCategory = "other"
+)
+
+func classifyFailure(err error) failureCategory {
+	switch {
+	case errors.Is(err, goproxy.ErrModuleNotFound):
+		return failureNotFound
+
+	case goproxy.IsTimeout(err):
+		return failureTimeout
+
+	case errors.Is(err, context.Canceled):
+		return failureCanceled
+
+	default:
+		return failureOther
+	}
+}
+
+// failureSummary collects the modules that failed during an enrichment, so that they can be reported and retried.
+type failureSummary struct {
+	mx      sync.Mutex
+	counts  map[failureCategory]int
+	modules []string
+}
+
+func newFailureSummary() *failureSummary {
+	return &failureSummary{
+		counts: map[failureCategory]int{},
+	}
+}
+
+func (s *failureSummary) add(category failureCategory, module string) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.counts[category]++
+	s.modules = append(s.modules, module)
+}
+
+// print writes the number of modules that succeeded the action (eg. enriched) and the failures by category.
+func (s *failureSummary) print(w io.Writer, action string, nbSucceeded int64) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	fmt.Fprintf(w, "%d modules %s, %d failed\n", nbSucceeded, action, len(s.modules))
+
+	categories := make([]failureCategory, 0, len(s.counts))
+	for category := range s.counts {
+		categories = append(categories, category)
+	}
+	slices.Sort(categories)
+
+	for _, category := range categories {
+		fmt.Fprintf(w, "  %s: %d\n", category, s.counts[category])
+	}
+}
+
+// writeModules writes the failed modules to a file, one per line.
+func (s *failureSummary) writeModules(path string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	for _, module := range s.modules {
+		if _, err := fmt.Fprintln(file, module); err != nil {
+			return fmt.Errorf("failed to write module: %w", err)
+		}
+	}
+
+	return file.Close()
+}
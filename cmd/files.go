@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/module"
+)
+
+type gzipFileWriter struct {
+	*gzip.Writer
+	file *os.File
+}
+
+func (w *gzipFileWriter) Close() error {
+	return errors.Join(w.Writer.Close(), w.file.Close())
+}
+
+type gzipFileReader struct {
+	*gzip.Reader
+	file *os.File
+}
+
+func (r *gzipFileReader) Close() error {
+	return errors.Join(r.Reader.Close(), r.file.Close())
+}
+
+// createOutputFile creates a file, compressed with gzip if compress is true or if its name ends with .gz.
+// The file must be closed to flush the compressed data.
+func createOutputFile(path string, compress bool) (io.WriteCloser, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if !compress && !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	return &gzipFileWriter{Writer: gzip.NewWriter(file), file: file}, nil
+}
+
+// openInputFile opens a file, decompressing it with gzip if its name ends with .gz.
+func openInputFile(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	if !strings.HasSuffix(path, ".gz") {
+		return file, nil
+	}
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read gzip header: %w", err)
+	}
+
+	return &gzipFileReader{Reader: reader, file: file}, nil
+}
+
+// iterFileModuleNames streams the module names of a file containing one "<path> [version]" per line, like the failed modules files.
+// It has the same contract as iterModuleNames, so that it can replace it to process a subset of the modules.
+func iterFileModuleNames(ctx context.Context, path string) (<-chan string, <-chan error) {
+	chNames := make(chan string, 1_000)
+	chErr := make(chan error, 1)
+
+	go func() {
+		defer close(chErr)
+		defer close(chNames)
+
+		file, err := openInputFile(path)
+		if err != nil {
+			chErr <- err
+			return
+		}
+		defer file.Close()
+
+		seen := map[string]struct{}{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 {
+				continue
+			}
+
+			if _, ok := seen[fields[0]]; ok {
+				continue
+			}
+			seen[fields[0]] = struct{}{}
+
+			select {
+			case chNames <- fields[0]:
+			case <-ctx.Done():
+				chErr <- ctx.Err()
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			chErr <- fmt.Errorf("failed to read file: %w", err)
+		}
+	}()
+
+	return chNames, chErr
+}
+
+// iterFileModuleVersions streams the module versions of a file containing one "<path> <version>" per line, like the failed
+// modules files of the commands enriching module versions. The lines without version are skipped.
+func iterFileModuleVersions(ctx context.Context, path string) (<-chan module.Version, <-chan error) {
+	chModules := make(chan module.Version, 1_000)
+	chErr := make(chan error, 1)
+
+	go func() {
+		defer close(chErr)
+		defer close(chModules)
+
+		file, err := openInputFile(path)
+		if err != nil {
+			chErr <- err
+			return
+		}
+		defer file.Close()
+
+		seen := map[module.Version]struct{}{}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 2 {
+				continue
+			}
+
+			m := module.Version{Path: fields[0], Version: fields[1]}
+			if _, ok := seen[m]; ok {
+				continue
+			}
+			seen[m] = struct{}{}
+
+			select {
+			case chModules <- m:
+			case <-ctx.Done():
+				chErr <- ctx.Err()
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			chErr <- fmt.Errorf("failed to read file: %w", err)
+		}
+	}()
+
+	return chModules, chErr
+}
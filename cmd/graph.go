@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+type moduleNode struct {
+	module.Version
+
+	// versionTime is left untouched on the node when zero.
+	versionTime time.Time
+
+	// retractions are the version intervals retracted by the go.mod file of the module.
+	// They are left untouched on the node when nil.
+	retractions []modfile.VersionInterval
+
+	// pathVersionMismatch is set when the major version suffix of the module path is inconsistent with the version major.
+	pathVersionMismatch bool
+}
+
+type moduleDependency struct {
+	module.Version
+
+	direct bool
+	tool   bool
+}
+
+type writeOptions struct {
+	orgRules []orgRule
+
+	// txTimeout is the timeout of each transaction creating the dependencies.
+	txTimeout time.Duration
+
+	// dependenciesBatchSize is the maximum number of dependencies created per transaction.
+	dependenciesBatchSize int
+
+	// nodesOnly and edgesOnly split the load in two passes over the same seed: the first one creates all the nodes,
+	// so that the second one only has to match them to create the relationships, which is much faster with the indexes.
+	nodesOnly bool
+	edgesOnly bool
+}
+
+// writeModule creates the node of a module, the nodes of its dependencies, and the relationships between them.
+func writeModule(ctx context.Context, driver neo4j.DriverWithContext, m moduleNode, dependencies []moduleDependency, options writeOptions) error {
+	logger := slog.With(slog.Any("module", m.Version))
+
+	var versionTime any
+	if !m.versionTime.IsZero() {
+		versionTime = m.versionTime
+	}
+
+	// The retracted intervals are stored as two lists of the same length, as Neo4j doesn't support nested properties
+	var retractLow, retractHigh any
+	if m.retractions != nil {
+		lows := make([]string, 0, len(m.retractions))
+		highs := make([]string, 0, len(m.retractions))
+		for _, retraction := range m.retractions {
+			lows = append(lows, retraction.Low)
+			highs = append(highs, retraction.High)
+		}
+
+		retractLow, retractHigh = lows, highs
+	}
+
+	// With edgesOnly, the node was already created with its properties by a nodesOnly pass
+	if !options.edgesOnly {
+		logger.Debug("creating module node")
+		if _, err := executeQuery(ctx, driver, `
+			MERGE (m:Module {name: $name, version: $version})
+			SET m.org = $org, m.host = $host, m.pathVersionMismatch = $pathVersionMismatch
+			FOREACH (_ IN CASE WHEN $versionTime IS NULL THEN [] ELSE [1] END |
+				SET m.versionTime = $versionTime
+			)
+			FOREACH (_ IN CASE WHEN $retractLow IS NULL THEN [] ELSE [1] END |
+				SET m.retractLow = $retractLow, m.retractHigh = $retractHigh
+			)
+			RETURN m
+		`, map[string]any{
+			"name":        m.Path,
+			"version":     m.Version.Version,
+			"org":         extractOrg(options.orgRules, m.Path),
+			"host":        extractHost(m.Path),
+			"versionTime": versionTime,
+			"retractLow":  retractLow,
+			"retractHigh": retractHigh,
+
+			"pathVersionMismatch": m.pathVersionMismatch,
+		}, neo4j.ExecuteQueryWithDatabase("")); err != nil {
+			logger.Error("failed to create module node", slog.Any("error", err))
+			return fmt.Errorf("failed to create module node: %w", err)
+		}
+	}
+
+	parameters := make([]map[string]any, 0, len(dependencies))
+	for _, dependency := range dependencies {
+		parameters = append(parameters, map[string]any{
+			"direct":            dependency.direct,
+			"tool":              dependency.tool,
+			"dependencyName":    dependency.Path,
+			"dependencyVersion": dependency.Version.Version,
+			"dependencyOrg":     extractOrg(options.orgRules, dependency.Path),
+			"dependencyHost":    extractHost(dependency.Path),
+			"dependentName":     m.Path,
+			"dependentVersion":  m.Version.Version,
+			"dependentOrg":      extractOrg(options.orgRules, m.Path),
+			"dependentHost":     extractHost(m.Path),
+		})
+	}
+
+	// Modules with a lot of dependencies are split in multiple transactions so that none of them times out
+	for start := 0; start < len(parameters); start += options.dependenciesBatchSize {
+		batch := parameters[start:min(start+options.dependenciesBatchSize, len(parameters))]
+
+		logger.Debug("creating module nodes and relationships for dependencies", slog.Int("dependenciesCount", len(batch)))
+		if _, err := executeQuery(ctx, driver, dependenciesQuery(options), map[string]any{
+			"dependencies": batch,
+		}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithTransactionConfig(neo4j.WithTxTimeout(options.txTimeout))); err != nil {
+			logger.Error("failed to create module nodes and relationships for dependencies",
+				slog.Int("dependenciesCount", len(batch)),
+				slog.Any("error", err))
+			return fmt.Errorf("failed to create module nodes and relationships: %w", err)
+		}
+	}
+
+	if options.nodesOnly {
+		return nil
+	}
+
+	// The module is only flagged once all its dependencies are written, so that an interrupted run can be resumed from the graph
+	logger.Debug("flagging module as processed")
+	if _, err := executeQuery(ctx, driver, `
+		MATCH (m:Module {name: $name, version: $version})
+		SET m.processed = true
+	`, map[string]any{
+		"name":    m.Path,
+		"version": m.Version.Version,
+	}, neo4j.ExecuteQueryWithDatabase("")); err != nil {
+		logger.Error("failed to flag module as processed", slog.Any("error", err))
+		return fmt.Errorf("failed to flag module as processed: %w", err)
+	}
+
+	return nil
+}
+
+// isPathVersionMismatch reports whether the major version suffix of a module path (eg. /v2 or .v2 for gopkg.in)
+// doesn't match the major of the version, eg. github.com/x/y/v2 at v3.0.0, or github.com/x/y at v2.0.0 without +incompatible.
+func isPathVersionMismatch(m module.Version) bool {
+	_, pathMajor, ok := module.SplitPathVersion(m.Path)
+	if !ok {
+		return true
+	}
+
+	return module.CheckPathMajor(m.Version, pathMajor) != nil
+}
+
+func dependenciesQuery(options writeOptions) string {
+	switch {
+	case options.nodesOnly:
+		return `
+			UNWIND $dependencies AS dep
+			MERGE (dependency:Module {name: dep.dependencyName, version: dep.dependencyVersion})
+			SET dependency.org = dep.dependencyOrg, dependency.host = dep.dependencyHost
+		`
+
+	case options.edgesOnly:
+		return `
+			UNWIND $dependencies AS dep
+			MATCH (dependency:Module {name: dep.dependencyName, version: dep.dependencyVersion})
+			MATCH (dependent:Module {name: dep.dependentName, version: dep.dependentVersion})
+			FOREACH (_ IN CASE WHEN dep.direct THEN [1] ELSE [] END |
+				MERGE (dependent)-[:DEPENDS_ON]->(dependency)
+				MERGE (dependency)-[:IS_DEPENDED_ON_BY]->(dependent)
+			)
+			FOREACH (_ IN CASE WHEN dep.tool THEN [1] ELSE [] END |
+				MERGE (dependent)-[:TOOL_DEPENDS_ON]->(dependency)
+				MERGE (dependency)-[:IS_TOOL_DEPENDED_ON_BY]->(dependent)
+			)
+		`
+
+	default:
+		return `
+			UNWIND $dependencies AS dep
+			MERGE (dependency:Module {name: dep.dependencyName, version: dep.dependencyVersion})
+			SET dependency.org = dep.dependencyOrg, dependency.host = dep.dependencyHost
+			MERGE (dependent:Module {name: dep.dependentName, version: dep.dependentVersion})
+			SET dependent.org = dep.dependentOrg, dependent.host = dep.dependentHost
+			FOREACH (_ IN CASE WHEN dep.direct THEN [1] ELSE [] END |
+				MERGE (dependent)-[:DEPENDS_ON]->(dependency)
+				MERGE (dependency)-[:IS_DEPENDED_ON_BY]->(dependent)
+			)
+			FOREACH (_ IN CASE WHEN dep.tool THEN [1] ELSE [] END |
+				MERGE (dependent)-[:TOOL_DEPENDS_ON]->(dependency)
+				MERGE (dependency)-[:IS_TOOL_DEPENDED_ON_BY]->(dependent)
+			)
+			RETURN dependency, dependent
+		`
+	}
+}
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/errgroup"
+)
+
+// csvColumns are the columns expected in the header of the files imported by import-csv, in any order:
+//   - module: the module path
+//   - version: the module version
+//   - time: the RFC 3339 time of the version, can be empty
+//   - dependencies: the space-separated "path@version" direct dependencies of the module, can be empty
+var csvColumns = []string{"module", "version", "time", "dependencies"}
+
+func ImportCSVHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		inputFile := command.Lookup[string](flagSet, "input-file")
+		orgRulesFile := command.Lookup[string](flagSet, "org-rules-file")
+		parallel := command.Lookup[int](flagSet, "parallel")
+		txTimeout := command.Lookup[time.Duration](flagSet, "tx-timeout")
+		dependenciesBatchSize := command.Lookup[int](flagSet, "dependencies-batch-size")
+
+		if dependenciesBatchSize <= 0 {
+			slog.Error("\"dependencies-batch-size\" must be positive", slog.Int("dependenciesBatchSize", dependenciesBatchSize))
+			return 1
+		}
+
+		orgRules, err := loadOrgRules(orgRulesFile)
+		if err != nil {
+			slog.Error("failed to load org rules", slog.Any("error", err))
+			return 1
+		}
+
+		options := writeOptions{
+			orgRules:              orgRules,
+			txTimeout:             txTimeout,
+			dependenciesBatchSize: dependenciesBatchSize,
+		}
+
+		slog.Debug("opening input file", slog.String("file", inputFile))
+		inputFileHandler, err := openInputFile(inputFile)
+		if err != nil {
+			slog.Error("failed to open input file", slog.String("file", inputFile), slog.Any("error", err))
+			return 1
+		}
+		defer inputFileHandler.Close()
+
+		reader := csv.NewReader(inputFileHandler)
+
+		header, err := reader.Read()
+		if err != nil {
+			slog.Error("failed to read input file header", slog.String("file", inputFile), slog.Any("error", err))
+			return 1
+		}
+
+		columns := map[string]int{}
+		for _, column := range csvColumns {
+			index := slices.Index(header, column)
+			if index == -1 {
+				slog.Error("missing column in input file header", slog.String("file", inputFile), slog.String("column", column))
+				return 1
+			}
+
+			columns[column] = index
+		}
+
+		g, gCtx := errgroup.WithContext(ctx)
+		g.SetLimit(parallel)
+
+		progress := progressbar.Default(-1, "importing modules")
+
+		var nbInvalid atomic.Int64
+		for {
+			record, err := reader.Read()
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					break
+				}
+
+				slog.Error("failed to read input file", slog.String("file", inputFile), slog.Any("error", err))
+				return 1
+			}
+
+			line, _ := reader.FieldPos(0)
+			m, versionTime, dependencies, err := parseCSVRecord(record, columns)
+			if err != nil {
+				slog.Warn("skipping invalid row", slog.String("file", inputFile), slog.Int("line", line), slog.Any("error", err))
+				nbInvalid.Add(1)
+				continue
+			}
+
+			g.Go(func() error {
+				defer func() {
+					if err := progress.Add(1); err != nil {
+						slog.Error("failed to update progress bar", slog.Any("error", err))
+					}
+				}()
+
+				return writeModule(gCtx, driver, moduleNode{Version: m, versionTime: versionTime, pathVersionMismatch: isPathVersionMismatch(m)}, dependencies, options)
+			})
+		}
+
+		if err := g.Wait(); err != nil {
+			slog.Error("failed to import modules", slog.Any("error", err))
+			return 1
+		}
+
+		_ = progress.Finish()
+		if nbInvalid.Load() > 0 {
+			slog.Warn("some rows were skipped", slog.Int64("count", nbInvalid.Load()))
+		}
+
+		return 0
+	}
+}
+
+func parseCSVRecord(record []string, columns map[string]int) (module.Version, time.Time, []moduleDependency, error) {
+	m := module.Version{
+		Path:    strings.ToLower(strings.TrimSpace(record[columns["module"]])),
+		Version: strings.TrimSpace(record[columns["version"]]),
+	}
+	if m.Path == "" || m.Version == "" {
+		return module.Version{}, time.Time{}, nil, errors.New("module and version are required")
+	}
+
+	var versionTime time.Time
+	if rawTime := strings.TrimSpace(record[columns["time"]]); rawTime != "" {
+		var err error
+		versionTime, err = time.Parse(time.RFC3339, rawTime)
+		if err != nil {
+			return module.Version{}, time.Time{}, nil, fmt.Errorf("invalid time %q: %w", rawTime, err)
+		}
+	}
+
+	rawDependencies := strings.Fields(record[columns["dependencies"]])
+	dependencies := make([]moduleDependency, 0, len(rawDependencies))
+	for _, rawDependency := range rawDependencies {
+		path, version, ok := strings.Cut(rawDependency, "@")
+		if !ok || path == "" || version == "" {
+			return module.Version{}, time.Time{}, nil, fmt.Errorf("invalid dependency %q, expected path@version", rawDependency)
+		}
+
+		dependencies = append(dependencies, moduleDependency{
+			Version: module.Version{Path: strings.ToLower(path), Version: version},
+			direct:  true,
+		})
+	}
+
+	return m, versionTime, dependencies, nil
+}
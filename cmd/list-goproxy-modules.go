@@ -3,18 +3,22 @@ package cmd
 import (
 	"context"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
-	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Thiht/go-command"
 	"github.com/Thiht/go-stats/goproxy"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/schollz/progressbar/v3"
+	"golang.org/x/mod/module"
+	"golang.org/x/sync/errgroup"
 )
 
-func ListGoProxyModulesHandler(goProxyClient goproxy.Client) command.Handler {
+func ListGoProxyModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy.Client) command.Handler {
 	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
 		since, err := time.Parse(time.RFC3339, command.Lookup[string](flagSet, "since"))
 		if err != nil {
@@ -22,6 +26,26 @@ func ListGoProxyModulesHandler(goProxyClient goproxy.Client) command.Handler {
 			return 1
 		}
 
+		if command.Lookup[bool](flagSet, "since-from-graph") {
+			graphSince, err := lastVersionTime(ctx, driver)
+			if err != nil {
+				slog.Error("failed to get the last version time from the graph", slog.Any("error", err))
+				return 1
+			}
+
+			// The version times are the commit or tag times, not the index timestamps, so a version tagged before the most recent one
+			// but indexed after it is missed. A pseudo-version can also be dated in the future, which would list nothing.
+			if graphSince.IsZero() {
+				slog.Info("no version time in the graph, fallback to \"since\"", slog.String("since", since.Format(time.RFC3339Nano)))
+			} else {
+				since = graphSince
+				if now := time.Now(); since.After(now) {
+					slog.Warn("last version time of the graph is in the future, clamping it to now", slog.Time("versionTime", since))
+					since = now
+				}
+			}
+		}
+
 		until, err := time.Parse(time.RFC3339, command.Lookup[string](flagSet, "until"))
 		if err != nil {
 			slog.Error("failed to parse \"until\"", slog.String("until", command.Lookup[string](flagSet, "until")), slog.Any("error", err))
@@ -29,14 +53,19 @@ func ListGoProxyModulesHandler(goProxyClient goproxy.Client) command.Handler {
 		}
 
 		outputFile := command.Lookup[string](flagSet, "output-file")
+		compress := command.Lookup[bool](flagSet, "gzip")
+		appendLatest := command.Lookup[bool](flagSet, "append-latest")
+		parallel := command.Lookup[int](flagSet, "parallel")
+
+		// The hosts are matched as path prefixes at element boundaries, so k8s.io matches k8s.io/api but not k8s.iox
+		onlyHosts := strings.Join(command.Lookup[[]string](flagSet, "only-hosts"), ",")
 
 		slog.Debug("opening output file", slog.String("file", outputFile))
-		outputFileHandler, err := os.Create(outputFile)
+		outputFileHandler, err := createOutputFile(outputFile, compress)
 		if err != nil {
 			slog.Error("failed to open output file", slog.String("file", outputFile), slog.Any("error", err))
 			return 1
 		}
-		defer outputFileHandler.Close()
 
 		nbDays := int64(until.Sub(since).Hours() / 24)
 		progress := progressbar.Default(nbDays, since.Format("2006-01-02"))
@@ -55,6 +84,11 @@ func ListGoProxyModulesHandler(goProxyClient goproxy.Client) command.Handler {
 
 				slog.Debug("received index", slog.Int("count", len(index)))
 
+				if len(index) == 0 {
+					slog.Debug("no more index to list")
+					break
+				}
+
 				since = index[len(index)-1].Timestamp
 				chIndex <- index
 
@@ -78,23 +112,81 @@ func ListGoProxyModulesHandler(goProxyClient goproxy.Client) command.Handler {
 			}
 		}()
 
+		// The latest versions are fetched concurrently, so the lines are written in completion order
+		var (
+			g       errgroup.Group
+			mxWrite sync.Mutex
+		)
+		g.SetLimit(parallel)
+
+		writeLine := func(path, line string) {
+			mxWrite.Lock()
+			defer mxWrite.Unlock()
+
+			if _, err := io.WriteString(outputFileHandler, line+"\n"); err != nil {
+				slog.Error("failed to write module", slog.String("module", path), slog.Any("error", err))
+			}
+		}
+
 		var modulesSet sync.Map
 		for index := range chIndex {
 			for _, i := range index {
 				path := strings.ToLower(i.Path)
 
+				// Filtering before the deduplication keeps the set as small as the filtered output
+				if onlyHosts != "" && !module.MatchPrefixPatterns(onlyHosts, path) {
+					continue
+				}
+
 				if _, ok := modulesSet.Load(path); ok {
 					continue
 				}
 				modulesSet.Store(path, struct{}{})
 
-				if _, err := outputFileHandler.WriteString(path + " " + i.Version + "\n"); err != nil {
-					slog.Error("failed to write module", slog.String("module", path), slog.Any("error", err))
+				if !appendLatest {
+					writeLine(path, path+" "+i.Version)
 					continue
 				}
+
+				g.Go(func() error {
+					// A module without a resolvable latest version is still listed, with an empty latest column
+					latest := ""
+					info, err := getLatestInfo(ctx, goProxyClient, path, 0)
+					if err != nil {
+						slog.Warn("failed to get latest module info", slog.String("module", path), slog.Any("error", err))
+					} else {
+						latest = info.Version
+					}
+
+					writeLine(path, path+" "+i.Version+" "+latest)
+					return nil
+				})
 			}
 		}
 
+		_ = g.Wait()
+
+		// The index listing stops when the context is canceled, so the output is also flushed on interruption
+		if err := outputFileHandler.Close(); err != nil {
+			slog.Error("failed to close output file", slog.String("file", outputFile), slog.Any("error", err))
+			return 1
+		}
+
 		return 0
 	}
 }
+
+// lastVersionTime returns the most recent version time stored in the graph, or a zero time if there is none.
+func lastVersionTime(ctx context.Context, driver neo4j.DriverWithContext) (time.Time, error) {
+	result, err := executeQuery(ctx, driver, "MATCH (m:Module) WHERE m.versionTime IS NOT NULL RETURN max(m.versionTime) AS since", nil, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to query the last version time: %w", err)
+	}
+
+	since, isNil, err := neo4j.GetRecordValue[time.Time](result.Records[0], "since")
+	if err != nil && !isNil {
+		return time.Time{}, fmt.Errorf("failed to read the last version time: %w", err)
+	}
+
+	return since, nil
+}
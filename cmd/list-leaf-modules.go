@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ListLeafModulesHandler lists the modules that depend on other modules but that no module depends on.
+// They are usually applications rather than libraries.
+func ListLeafModulesHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		top := command.Lookup[int](flagSet, "top")
+		byDeps := command.Lookup[bool](flagSet, "by-deps")
+		format := command.Lookup[string](flagSet, "format")
+
+		orderBy := "name, version"
+		if byDeps {
+			orderBy = "dependencies DESC, " + orderBy
+		}
+
+		limit := ""
+		if top > 0 {
+			limit = "LIMIT " + strconv.Itoa(top)
+		}
+
+		slog.Debug("listing leaf modules")
+		result, err := executeQuery(ctx, driver, `
+			MATCH (m:Module)
+			WHERE NOT (m)<-[:DEPENDS_ON]-() AND (m)-[:DEPENDS_ON]->()
+			RETURN m.name AS name, m.version AS version, COUNT { (m)-[:DEPENDS_ON]->() } AS dependencies
+			ORDER BY `+orderBy+`
+			`+limit, nil, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+		if err != nil {
+			slog.Error("failed to list leaf modules", slog.Any("error", err))
+			return 1
+		}
+
+		rows := make([][]string, 0, len(result.Records))
+		for _, record := range result.Records {
+			name, _, _ := neo4j.GetRecordValue[string](record, "name")
+			version, _, _ := neo4j.GetRecordValue[string](record, "version")
+			dependencies, _, _ := neo4j.GetRecordValue[int64](record, "dependencies")
+
+			rows = append(rows, []string{name, version, strconv.FormatInt(dependencies, 10)})
+		}
+
+		if err := writeRows(os.Stdout, format, []string{"module", "version", "dependencies"}, rows); err != nil {
+			slog.Error("failed to write leaf modules", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/goproxy"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	goModMissing     = "missing"
+	goModSynthesized = "synthesized"
+)
+
+// MissingGoModHandler reports the modules whose latest version has no go.mod file, either because the proxy
+// doesn't serve any, or because it serves a go.mod synthesized from the module path (GOPATH-era modules).
+func MissingGoModHandler(driver neo4j.DriverWithContext, goProxyClient goproxy.Client) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		seedFile := command.Lookup[string](flagSet, "seed-file")
+		parallel := command.Lookup[int](flagSet, "parallel")
+		outputFile := command.Lookup[string](flagSet, "output-file")
+		compress := command.Lookup[bool](flagSet, "gzip")
+
+		var (
+			chNames <-chan string
+			chErr   <-chan error
+		)
+		if seedFile != "" {
+			chNames, chErr = iterFileModuleNames(ctx, seedFile)
+		} else {
+			chNames, chErr = iterModuleNames(ctx, driver)
+		}
+
+		progress := progressbar.Default(-1, "checking go.mod files")
+		failures := newFailureSummary()
+
+		var (
+			rows   [][]string
+			mxRows sync.Mutex
+		)
+
+		var g errgroup.Group
+		g.SetLimit(parallel)
+
+		var nbChecked int64
+		for name := range chNames {
+			nbChecked++
+			g.Go(func() error {
+				defer func() {
+					if err := progress.Add(1); err != nil {
+						slog.Error("failed to update progress bar", slog.Any("error", err))
+					}
+				}()
+
+				info, err := getLatestInfo(ctx, goProxyClient, name, 0)
+				if err != nil {
+					slog.Warn("failed to get latest module info", slog.String("module", name), slog.Any("error", err))
+					failures.add(classifyFailure(err), name)
+					return nil
+				}
+
+				status, err := goModStatus(ctx, goProxyClient, name, info.Version)
+				if err != nil {
+					slog.Warn("failed to get module go.mod file", slog.String("module", name), slog.String("version", info.Version), slog.Any("error", err))
+					failures.add(classifyFailure(err), name)
+					return nil
+				}
+
+				if status != "" {
+					mxRows.Lock()
+					rows = append(rows, []string{name, info.Version, status})
+					mxRows.Unlock()
+				}
+
+				return nil
+			})
+		}
+
+		_ = g.Wait()
+
+		if err := <-chErr; err != nil {
+			slog.Error("failed to list modules to check", slog.Any("error", err))
+			return 1
+		}
+
+		_ = progress.Finish()
+
+		slices.SortFunc(rows, func(a, b []string) int {
+			return strings.Compare(a[0], b[0])
+		})
+
+		slog.Debug("opening output file", slog.String("file", outputFile))
+		outputFileHandler, err := createOutputFile(outputFile, compress)
+		if err != nil {
+			slog.Error("failed to open output file", slog.String("file", outputFile), slog.Any("error", err))
+			return 1
+		}
+		defer outputFileHandler.Close()
+
+		if err := writeRows(outputFileHandler, "csv", []string{"module", "version", "status"}, rows); err != nil {
+			slog.Error("failed to write modules without go.mod", slog.Any("error", err))
+			return 1
+		}
+
+		if err := outputFileHandler.Close(); err != nil {
+			slog.Error("failed to close output file", slog.String("file", outputFile), slog.Any("error", err))
+			return 1
+		}
+
+		nbMissing := 0
+		for _, row := range rows {
+			if row[2] == goModMissing {
+				nbMissing++
+			}
+		}
+
+		failures.print(os.Stdout, "checked", nbChecked-int64(len(failures.modules)))
+		fmt.Printf("%d modules without go.mod (%d missing, %d synthesized)\n", len(rows), nbMissing, len(rows)-nbMissing)
+
+		return 0
+	}
+}
+
+// goModStatus returns goModMissing or goModSynthesized if the version of the module has no go.mod file, or an empty string if it has one.
+func goModStatus(ctx context.Context, goProxyClient goproxy.Client, modulePath, version string) (string, error) {
+	modFile, err := goProxyClient.GetModuleModFile(ctx, modulePath, version, true)
+	if errors.Is(err, goproxy.ErrModuleNotFound) {
+		modFile, err = goProxyClient.GetModuleModFile(ctx, modulePath, version, false)
+	}
+	if err != nil {
+		// The version exists since its info was found, so a not found .mod means the go.mod itself is missing
+		if errors.Is(err, goproxy.ErrModuleNotFound) {
+			return goModMissing, nil
+		}
+
+		return "", err
+	}
+
+	if isSynthesizedModFile(modFile) {
+		return goModSynthesized, nil
+	}
+
+	return "", nil
+}
+
+// isSynthesizedModFile reports whether the go.mod file only contains a module directive, which is what the proxy serves for modules without go.mod.
+func isSynthesizedModFile(modFile *modfile.File) bool {
+	return modFile.Module != nil && modFile.Syntax != nil && len(modFile.Syntax.Stmt) == 1
+}
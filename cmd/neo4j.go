@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+const maxQueryRetries = 5
+
+// The queries are written with the default label and relationship type, and rewritten with the configured ones,
+// so that several graphs can be stored side by side in the same database.
+var (
+	nodeLabel        = "Module"
+	relationshipType = "DEPENDS_ON"
+)
+
+var reSchemaName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SetGraphSchema configures the label of the module nodes and the type of the dependency relationships.
+func SetGraphSchema(label, relType string) error {
+	if !reSchemaName.MatchString(label) {
+		return fmt.Errorf("invalid node label %q", label)
+	}
+
+	if !reSchemaName.MatchString(relType) {
+		return fmt.Errorf("invalid relationship type %q", relType)
+	}
+
+	nodeLabel = label
+	relationshipType = relType
+
+	return nil
+}
+
+// NodeLabel returns the configured label of the module nodes.
+func NodeLabel() string {
+	return nodeLabel
+}
+
+// withGraphSchema rewrites the default label and relationship types of a query. The tool and reverse relationship types
+// are derived from the configured type, eg. TOOL_USES, USES_INVERSE and TOOL_USES_INVERSE for USES, so that they aren't shared between graphs.
+func withGraphSchema(query string) string {
+	if nodeLabel == "Module" && relationshipType == "DEPENDS_ON" {
+		return query
+	}
+
+	replacements := []string{":Module", ":" + nodeLabel}
+	if relationshipType != "DEPENDS_ON" {
+		toolType := "TOOL_" + relationshipType
+		replacements = append(replacements,
+			":DEPENDS_ON", ":"+relationshipType,
+			":TOOL_DEPENDS_ON", ":"+toolType,
+			"|TOOL_DEPENDS_ON", "|"+toolType,
+			":IS_DEPENDED_ON_BY", ":"+relationshipType+"_INVERSE",
+			":IS_TOOL_DEPENDED_ON_BY", ":"+toolType+"_INVERSE",
+		)
+	}
+
+	return strings.NewReplacer(replacements...).Replace(query)
+}
+
+// executeQuery wraps neo4j.ExecuteQuery to retry the query with an exponential backoff on transient errors.
+// They typically happen when concurrent transactions deadlock on the same nodes, and are safe to retry.
+func executeQuery(ctx context.Context, driver neo4j.DriverWithContext, query string, parameters map[string]any, configurers ...neo4j.ExecuteQueryConfigurationOption) (*neo4j.EagerResult, error) {
+	return backoff.RetryWithData(func() (*neo4j.EagerResult, error) {
+		result, err := neo4j.ExecuteQuery(ctx, driver, withGraphSchema(query), parameters, neo4j.EagerResultTransformer, configurers...)
+		if err != nil {
+			if !isTransientError(err) {
+				return nil, backoff.Permanent(err)
+			}
+
+			slog.Warn("transient error while executing query, retrying", slog.Any("error", err))
+			return nil, err
+		}
+
+		return result, nil
+	}, backoff.WithContext(backoff.WithMaxRetries(backoff.NewExponentialBackOff(), maxQueryRetries), ctx))
+}
+
+func isTransientError(err error) bool {
+	var neo4jErr *neo4j.Neo4jError
+	if errors.As(err, &neo4jErr) {
+		return neo4jErr.Classification() == "TransientError"
+	}
+
+	// The driver already retries the transaction internally and wraps the errors once it gives up
+	var limitErr *neo4j.TransactionExecutionLimit
+	if errors.As(err, &limitErr) {
+		for _, err := range limitErr.Errors {
+			if isTransientError(err) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return neo4j.IsRetryable(err)
+}
+
+// iterModuleNames streams the distinct names of the module nodes.
+// The error channel receives at most one error, and is closed once all the names have been sent.
+func iterModuleNames(ctx context.Context, driver neo4j.DriverWithContext) (<-chan string, <-chan error) {
+	chNames := make(chan string, 1_000)
+	chErr := make(chan error, 1)
+
+	go func() {
+		defer close(chErr)
+		defer close(chNames)
+
+		session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: "", AccessMode: neo4j.AccessModeRead})
+		defer session.Close(ctx)
+
+		slog.Debug("listing module names")
+		result, err := session.Run(ctx, withGraphSchema("MATCH (m:Module) RETURN DISTINCT m.name AS name"), nil)
+		if err != nil {
+			chErr <- fmt.Errorf("failed to list module names: %w", err)
+			return
+		}
+
+		seen := map[string]struct{}{}
+		for result.Next(ctx) {
+			name, _, err := neo4j.GetRecordValue[string](result.Record(), "name")
+			if err != nil {
+				chErr <- fmt.Errorf("failed to read module name: %w", err)
+				return
+			}
+
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			seen[name] = struct{}{}
+
+			select {
+			case chNames <- name:
+			case <-ctx.Done():
+				chErr <- ctx.Err()
+				return
+			}
+		}
+		if err := result.Err(); err != nil {
+			chErr <- fmt.Errorf("failed to list module names: %w", err)
+		}
+	}()
+
+	return chNames, chErr
+}
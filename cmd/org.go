@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+type orgRule struct {
+	prefix string
+	org    string
+}
+
+// defaultOrgRules maps module path prefixes to the organization owning them.
+// The first matching rule wins, so the most specific prefixes must come first.
+var defaultOrgRules = []orgRule{
+	{prefix: "github.com/pkg/", org: "golang"},
+	{prefix: "google.golang.org/", org: "google"},
+	{prefix: "golang.org/", org: "golang"},
+	{prefix: "k8s.io/", org: "kubernetes"},
+	{prefix: "sigs.k8s.io/", org: "kubernetes"},
+	{prefix: "go.uber.org/", org: "uber-go"},
+	{prefix: "gorm.io/gorm", org: "go-gorm"},
+	{prefix: "go.opentelemetry.io/", org: "open-telemetry"},
+	{prefix: "go.mongodb.org/", org: "mongodb"},
+	{prefix: "go.etcd.io/", org: "etcd-io"},
+}
+
+// loadOrgRules reads org rules from a file containing one "<prefix> <org>" rule per line.
+// Empty lines and lines starting with # are ignored. Without a file, the default rules are used.
+func loadOrgRules(rulesFile string) ([]orgRule, error) {
+	if rulesFile == "" {
+		return defaultOrgRules, nil
+	}
+
+	slog.Debug("opening org rules file", slog.String("file", rulesFile))
+	rulesFileHandler, err := os.Open(rulesFile)
+	if err != nil {
+		slog.Error("failed to open org rules file", slog.String("file", rulesFile), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to open org rules file: %w", err)
+	}
+	defer rulesFileHandler.Close()
+
+	var rules []orgRule
+	scanner := bufio.NewScanner(rulesFileHandler)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			slog.Error("invalid org rule", slog.String("file", rulesFile), slog.Int("line", lineNumber), slog.String("rule", line))
+			return nil, fmt.Errorf("invalid org rule at line %d: %q", lineNumber, line)
+		}
+
+		rules = append(rules, orgRule{prefix: fields[0], org: fields[1]})
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("failed to read org rules file", slog.String("file", rulesFile), slog.Any("error", err))
+		return nil, fmt.Errorf("failed to read org rules file: %w", err)
+	}
+
+	slog.Debug("loaded org rules", slog.Int("count", len(rules)))
+
+	return rules, nil
+}
+
+func extractOrg(rules []orgRule, modulePath string) string {
+	for _, rule := range rules {
+		if strings.HasPrefix(modulePath, rule.prefix) {
+			return rule.org
+		}
+	}
+
+	if strings.HasPrefix(modulePath, "github.com/") {
+		return strings.Split(modulePath, "/")[1]
+	}
+
+	return ""
+}
+
+func extractHost(modulePath string) string {
+	host, _, _ := strings.Cut(modulePath, "/")
+	return host
+}
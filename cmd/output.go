@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// writeRows writes the result of a query command as an aligned table or as CSV.
+func writeRows(w io.Writer, format string, header []string, rows [][]string) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(tw, strings.Join(header, "\t")); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(tw, strings.Join(row, "\t")); err != nil {
+				return fmt.Errorf("failed to write row: %w", err)
+			}
+		}
+
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to flush table: %w", err)
+		}
+
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(header); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+
+		if err := cw.WriteAll(rows); err != nil {
+			return fmt.Errorf("failed to write rows: %w", err)
+		}
+
+	default:
+		return fmt.Errorf("unsupported format %q, expected table or csv", format)
+	}
+
+	return nil
+}
@@ -7,16 +7,19 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
-	"net"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/Thiht/go-command"
 	"github.com/Thiht/go-stats/goproxy"
+	"github.com/Thiht/go-stats/semver"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"github.com/schollz/progressbar/v3"
+	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/module"
 	"golang.org/x/sync/errgroup"
 )
@@ -25,6 +28,73 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
 		parallel := command.Lookup[int](flagSet, "parallel")
 		seedFile := command.Lookup[string](flagSet, "seed-file")
+		orgRulesFile := command.Lookup[string](flagSet, "org-rules-file")
+		onlyLatest := command.Lookup[bool](flagSet, "only-latest")
+		excludedDependencyPrefixes := command.Lookup[[]string](flagSet, "exclude-dep-prefix")
+		nodesOnly := command.Lookup[bool](flagSet, "nodes-only")
+		edgesOnly := command.Lookup[bool](flagSet, "edges-only")
+		resumeFromGraph := command.Lookup[bool](flagSet, "resume-from-graph")
+		resumeBatchSize := command.Lookup[int](flagSet, "resume-batch-size")
+		sampleRate := command.Lookup[float64](flagSet, "sample")
+		sampleSeed := command.Lookup[int64](flagSet, "sample-seed")
+		rawMinGoVersion := command.Lookup[string](flagSet, "min-go-version")
+		includeNoGoDirective := command.Lookup[bool](flagSet, "include-no-go-directive")
+		txTimeout := command.Lookup[time.Duration](flagSet, "tx-timeout")
+		dependenciesBatchSize := command.Lookup[int](flagSet, "dependencies-batch-size")
+
+		if command.Lookup[bool](flagSet, "validate-only") {
+			return validateSeedFile(seedFile)
+		}
+
+		if dependenciesBatchSize <= 0 {
+			slog.Error("\"dependencies-batch-size\" must be positive", slog.Int("dependenciesBatchSize", dependenciesBatchSize))
+			return 1
+		}
+
+		if nodesOnly && edgesOnly {
+			slog.Error("\"nodes-only\" and \"edges-only\" are mutually exclusive")
+			return 1
+		}
+
+		sample, err := newSampler(sampleRate, sampleSeed)
+		if err != nil {
+			slog.Error("invalid \"sample\"", slog.Any("error", err))
+			return 1
+		}
+
+		var minGoVersion []int
+		if rawMinGoVersion != "" {
+			var ok bool
+			minGoVersion, ok = parseGoVersion(rawMinGoVersion)
+			if !ok {
+				slog.Error("invalid \"min-go-version\", expected a go version such as 1.18", slog.String("minGoVersion", rawMinGoVersion))
+				return 1
+			}
+		}
+
+		orgRules, err := loadOrgRules(orgRulesFile)
+		if err != nil {
+			slog.Error("failed to load org rules", slog.Any("error", err))
+			return 1
+		}
+
+		options := processModuleOptions{
+			writeOptions: writeOptions{
+				orgRules:              orgRules,
+				txTimeout:             txTimeout,
+				dependenciesBatchSize: dependenciesBatchSize,
+				nodesOnly:             nodesOnly,
+				edgesOnly:             edgesOnly,
+			},
+			excludedDependencyPrefixes: excludedDependencyPrefixes,
+			minGoVersion:               minGoVersion,
+			includeNoGoDirective:       includeNoGoDirective,
+			timings:                    newModuleTimings(),
+		}
+
+		if command.Lookup[bool](flagSet, "stdout-edges") {
+			options.edges = newEdgeWriter(os.Stdout)
+		}
 
 		initialModules, err := loadInitialModules(seedFile)
 		if err != nil {
@@ -32,6 +102,30 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 			return 1
 		}
 
+		if onlyLatest {
+			// Processing a module without version resolves its latest version
+			for i := range initialModules {
+				initialModules[i].Version = ""
+			}
+		}
+
+		initialModules = slices.DeleteFunc(initialModules, func(m module.Version) bool {
+			return !sample.keep(m.Path)
+		})
+
+		// The seed modules already processed aren't fetched again, but their dependencies from the graph are still queued to resume the crawl
+		var resumedModules map[string][]module.Version
+		if resumeFromGraph {
+			resumedModules, err = listProcessedModules(ctx, driver, initialModules, resumeBatchSize)
+			if err != nil {
+				slog.Error("failed to list processed modules", slog.Any("error", err))
+				return 1
+			}
+
+			slog.Info("resuming from graph", slog.Int("processedModules", len(resumedModules)))
+		}
+
+		start := time.Now()
 		nbModules := int64(len(initialModules))
 		var mxNbModules sync.Mutex
 
@@ -40,9 +134,15 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 
 		progress := progressbar.Default(nbModules)
 
+		// pending counts the modules queued or being processed, so that the queue is closed once they are all processed
+		var pending sync.WaitGroup
+		pending.Add(1)
+
 		var pendingModules sync.Map
 		chModules := make(chan module.Version, 1_000)
 		go func() {
+			defer pending.Done()
+
 			for _, m := range initialModules {
 				if _, loaded := pendingModules.LoadOrStore(m.Path, struct{}{}); loaded {
 					mxNbModules.Lock()
@@ -53,14 +153,22 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 				}
 
 				slog.Debug("adding module to processing queue", slog.String("module", m.Path))
+				pending.Add(1)
 				chModules <- m
 			}
+		}()
+
+		go func() {
+			pending.Wait()
 
 			slog.Debug("closing module channel")
+			close(chModules)
 		}()
 
 		for m := range chModules {
 			g.Go(func() error {
+				defer pending.Done()
+
 				sem <- struct{}{}
 				defer func() {
 					if err := progress.Add(1); err != nil {
@@ -72,17 +180,31 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 
 				slog.Debug("processing module", slog.String("module", m.Path))
 
-				dependencies, err := processModule(gCtx, m, goProxyClient, driver)
-				if err != nil {
-					slog.Error("failed to process module", slog.String("module", m.Path), slog.Any("error", err))
-					return err
+				dependencies, resumed := resumedModules[m.Path]
+				if resumed {
+					slog.Debug("module already processed, queuing its dependencies from the graph", slog.String("module", m.Path))
+				} else {
+					var err error
+					dependencies, err = processModule(gCtx, m, goProxyClient, driver, options)
+					if err != nil {
+						slog.Error("failed to process module", slog.String("module", m.Path), slog.Any("error", err))
+						return err
+					}
 				}
 
 				chDependencies := make(chan module.Version, len(dependencies))
+				pending.Add(1)
 				go func() {
+					defer pending.Done()
+
 					var loadedDependencies int64
 					for dependency := range chDependencies {
+						if !sample.keep(dependency.Path) {
+							continue
+						}
+
 						if _, loaded := pendingModules.LoadOrStore(dependency.Path, struct{}{}); !loaded {
+							pending.Add(1)
 							chModules <- dependency
 							loadedDependencies++
 						}
@@ -108,11 +230,22 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 
 		if err := g.Wait(); err != nil {
 			slog.Error("failed to process repositories", slog.Any("error", err))
-			os.Exit(1)
+			return 1
 		}
 
-		// close(chModules)
 		close(sem)
+		_ = progress.Finish()
+
+		// The edges are written to stdout, so the summary mustn't be mixed with them
+		summary := os.Stdout
+		if options.edges != nil {
+			summary = os.Stderr
+		}
+
+		if err := options.timings.print(summary, time.Since(start)); err != nil {
+			slog.Error("failed to write timings", slog.Any("error", err))
+			return 1
+		}
 
 		return 0
 	}
@@ -120,7 +253,7 @@ func ProcessModulesHandler(driver neo4j.DriverWithContext, goProxyClient goproxy
 
 func loadInitialModules(seedFile string) ([]module.Version, error) {
 	slog.Debug("opening seed file", slog.String("file", seedFile))
-	seedFileHandler, err := os.Open(seedFile)
+	seedFileHandler, err := openInputFile(seedFile)
 	if err != nil {
 		slog.Error("failed to open seed file", slog.String("file", seedFile), slog.Any("error", err))
 		return nil, fmt.Errorf("failed to open seed file: %w", err)
@@ -140,10 +273,25 @@ func loadInitialModules(seedFile string) ([]module.Version, error) {
 	modules := make([]module.Version, 0, estimatedCount)
 	scanner := bufio.NewScanner(seedFileHandler)
 	for scanner.Scan() {
-		modulePath := scanner.Text()
-		modules = append(modules, module.Version{
-			Path: strings.ToLower(modulePath),
-		})
+		// Each line contains a module path, optionally followed by a version
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		m := module.Version{
+			Path: strings.ToLower(fields[0]),
+		}
+		if len(fields) > 1 {
+			if !semver.Valid(fields[1]) {
+				slog.Warn("skipping seed module with invalid version", slog.String("module", m.Path), slog.String("version", fields[1]))
+				continue
+			}
+
+			m.Version = fields[1]
+		}
+
+		modules = append(modules, m)
 	}
 	if err := scanner.Err(); err != nil {
 		slog.Error("failed to read seed file", slog.String("file", seedFile), slog.Any("error", err))
@@ -155,15 +303,103 @@ func loadInitialModules(seedFile string) ([]module.Version, error) {
 	return modules, nil
 }
 
-func processModule(ctx context.Context, modulePath module.Version, goProxyClient goproxy.Client, driver neo4j.DriverWithContext) ([]module.Version, error) {
+// validateSeedFile checks that every line of the seed file has a valid module path and an optional valid version,
+// and reports the invalid lines without any request to the proxy or Neo4j.
+func validateSeedFile(seedFile string) int {
+	slog.Debug("opening seed file", slog.String("file", seedFile))
+	seedFileHandler, err := openInputFile(seedFile)
+	if err != nil {
+		slog.Error("failed to open seed file", slog.String("file", seedFile), slog.Any("error", err))
+		return 1
+	}
+	defer seedFileHandler.Close()
+
+	var (
+		nbValid      int
+		invalidLines []string
+	)
+
+	scanner := bufio.NewScanner(seedFileHandler)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		var reason string
+		switch {
+		case len(fields) > 2:
+			reason = fmt.Sprintf("expected \"<path> [version]\", got %d fields", len(fields))
+		case len(fields) > 1 && !semver.Valid(fields[1]):
+			reason = fmt.Sprintf("invalid version %q", fields[1])
+		default:
+			// The paths are lowercased when loaded, so they are checked the same way
+			if err := module.CheckPath(strings.ToLower(fields[0])); err != nil {
+				reason = err.Error()
+			}
+		}
+
+		if reason != "" {
+			invalidLines = append(invalidLines, fmt.Sprintf("line %d: %s", lineNumber, reason))
+			continue
+		}
+
+		nbValid++
+	}
+	if err := scanner.Err(); err != nil {
+		slog.Error("failed to read seed file", slog.String("file", seedFile), slog.Any("error", err))
+		return 1
+	}
+
+	fmt.Printf("%d valid, %d invalid\n", nbValid, len(invalidLines))
+	for _, line := range invalidLines {
+		fmt.Printf("  %s\n", line)
+	}
+
+	if len(invalidLines) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+type processModuleOptions struct {
+	writeOptions
+
+	// excludedDependencyPrefixes are the path prefixes of the dependencies that are neither linked nor processed.
+	excludedDependencyPrefixes []string
+
+	// minGoVersion is the minimum go directive of the processed modules, as parsed by parseGoVersion, or nil to process all of them.
+	minGoVersion         []int
+	includeNoGoDirective bool
+
+	// edges receives the dependency edges instead of Neo4j when set.
+	edges *edgeWriter
+
+	// timings records the time spent fetching and writing each module.
+	timings *moduleTimings
+}
+
+func processModule(ctx context.Context, modulePath module.Version, goProxyClient goproxy.Client, driver neo4j.DriverWithContext, options processModuleOptions) ([]module.Version, error) {
 	logger := slog.With(slog.Any("module", modulePath))
 
+	// The fetch duration includes the modules skipped or failed before being written, since they still cost proxy requests
+	start := time.Now()
+	var (
+		writeDuration time.Duration
+		outcome       = moduleFailed
+	)
+	defer func() {
+		options.timings.record(time.Since(start)-writeDuration, writeDuration, outcome)
+	}()
+
+	var moduleInfo goproxy.ModuleInfo
 	if modulePath.Version == "" {
 		logger.Debug("getting latest module info")
-		moduleInfo, err := goProxyClient.GetModuleLatestInfo(ctx, modulePath.Path, true)
+		var err error
+		moduleInfo, err = goProxyClient.GetModuleLatestInfo(ctx, modulePath.Path, true)
 		if err != nil {
-			var netErr net.Error
-			if errors.As(err, &netErr) && netErr.Timeout() {
+			if goproxy.IsTimeout(err) {
 				logger.Error("timeout while getting latest module info", slog.Any("error", err), slog.Bool("cached", true))
 				return nil, nil
 			}
@@ -175,7 +411,7 @@ func processModule(ctx context.Context, modulePath module.Version, goProxyClient
 
 			moduleInfo, err = goProxyClient.GetModuleLatestInfo(ctx, modulePath.Path, false)
 			if err != nil {
-				if errors.As(err, &netErr) && netErr.Timeout() {
+				if goproxy.IsTimeout(err) {
 					logger.Error("timeout while getting latest module info", slog.Any("error", err), slog.Bool("cached", false))
 					return nil, nil
 				}
@@ -193,12 +429,20 @@ func processModule(ctx context.Context, modulePath module.Version, goProxyClient
 		}
 
 		modulePath.Version = moduleInfo.Version
+	} else {
+		logger.Debug("getting module info")
+		var err error
+		// Like the latest info, the info of a version missing from the proxy cache is fetched from the origin
+		moduleInfo, err = getModuleInfo(ctx, goProxyClient, modulePath)
+		if err != nil {
+			// The version time is only informative, so the module can still be processed without it
+			logger.Warn("failed to get module info", slog.Any("error", err))
+		}
 	}
 
 	modFile, err := goProxyClient.GetModuleModFile(ctx, modulePath.Path, modulePath.Version, true)
 	if err != nil {
-		var netErr net.Error
-		if errors.As(err, &netErr) && netErr.Timeout() {
+		if goproxy.IsTimeout(err) {
 			logger.Error("timeout while getting module go.mod file", slog.Any("error", err), slog.Bool("cached", true))
 			return nil, nil
 		}
@@ -215,7 +459,7 @@ func processModule(ctx context.Context, modulePath module.Version, goProxyClient
 
 		modFile, err = goProxyClient.GetModuleModFile(ctx, modulePath.Path, modulePath.Version, false)
 		if err != nil {
-			if errors.As(err, &netErr) && netErr.Timeout() {
+			if goproxy.IsTimeout(err) {
 				logger.Error("timeout while getting module go.mod file", slog.Any("error", err), slog.Bool("cached", false))
 				return nil, nil
 			}
@@ -241,104 +485,146 @@ func processModule(ctx context.Context, modulePath module.Version, goProxyClient
 		return nil, nil
 	}
 
-	logger.Debug("creating module node", slog.String("name", modFile.Module.Mod.Path), slog.String("version", modFile.Module.Mod.Version))
-	if _, err := neo4j.ExecuteQuery(ctx, driver, "MERGE (m:Module {name: $name, version: $version}) RETURN m", map[string]any{
-		"name":    modFile.Module.Mod.Path,
-		"version": modFile.Module.Mod.Version,
-	}, neo4j.EagerResultTransformer, neo4j.ExecuteQueryWithDatabase("")); err != nil {
-		logger.Error("failed to create module node", slog.String("name", modFile.Module.Mod.Path), slog.Any("error", err))
-		return nil, fmt.Errorf("failed to create module node: %w", err)
+	if options.minGoVersion != nil {
+		if modFile.Go == nil {
+			if !options.includeNoGoDirective {
+				logger.Debug("skipping module without go directive")
+				outcome = moduleSkipped
+				return nil, nil
+			}
+		} else if goVersion, ok := parseGoVersion(modFile.Go.Version); !ok || slices.Compare(goVersion, options.minGoVersion) < 0 {
+			logger.Debug("skipping module below the minimum go version", slog.String("goVersion", modFile.Go.Version))
+			outcome = moduleSkipped
+			return nil, nil
+		}
 	}
 
-	logger.Debug("processing direct dependencies")
+	logger.Debug("processing direct and tool dependencies")
 
-	dependencies := make([]map[string]any, 0, len(modFile.Require))
+	dependencies := make([]moduleDependency, 0, len(modFile.Require))
 	dependsOn := make([]module.Version, 0, len(modFile.Require))
 
+	tools := toolModules(modFile)
 	for _, dependency := range modFile.Require {
-		if dependency.Indirect {
+		// Tool dependencies are usually marked as indirect because they are not imported by the module code
+		isTool := tools[dependency.Mod.Path]
+		if dependency.Indirect && !isTool {
 			continue
 		}
 
 		dependency.Mod.Path = strings.ToLower(dependency.Mod.Path)
-		dependsOn = append(dependsOn, dependency.Mod)
+		if hasAnyPrefix(dependency.Mod.Path, options.excludedDependencyPrefixes) {
+			logger.Debug("skipping excluded dependency", slog.String("dependency", dependency.Mod.Path))
+			continue
+		}
 
-		dependencies = append(dependencies, map[string]any{
-			"dependencyName":    dependency.Mod.Path,
-			"dependencyVersion": dependency.Mod.Version,
-			"dependencyOrg":     extractOrg(dependency.Mod.Path),
-			"dependentName":     modFile.Module.Mod.Path,
-			"dependentVersion":  modFile.Module.Mod.Version,
-			"dependentOrg":      extractOrg(modFile.Module.Mod.Path),
+		dependsOn = append(dependsOn, dependency.Mod)
+		dependencies = append(dependencies, moduleDependency{
+			Version: dependency.Mod,
+			direct:  !dependency.Indirect,
+			tool:    isTool,
 		})
 	}
 
-	logger.Debug("creating module nodes and relationships for dependencies",
-		slog.String("dependent", modFile.Module.Mod.Path),
-		slog.String("dependentVersion", modFile.Module.Mod.Version),
-		slog.Int("dependenciesCount", len(dependencies)))
-
-	if _, err := neo4j.ExecuteQuery(ctx, driver, `
-		UNWIND $dependencies AS dep
-		MERGE (dependency:Module {name: dep.dependencyName, version: dep.dependencyVersion, org: dep.dependencyOrg})
-		MERGE (dependent:Module {name: dep.dependentName, version: dep.dependentVersion, org: dep.dependentOrg})
-		MERGE (dependent)-[:DEPENDS_ON]->(dependency)
-		MERGE (dependency)-[:IS_DEPENDED_ON_BY]->(dependent)
-		RETURN dependency, dependent
-	`, map[string]any{
-		"dependencies": dependencies,
-	}, neo4j.EagerResultTransformer, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithTransactionConfig(neo4j.WithTxTimeout(3*time.Second))); err != nil {
-		logger.Error("failed to create module nodes and relationships for dependencies",
-			slog.String("dependent", modFile.Module.Mod.Path),
-			slog.String("dependentVersion", modFile.Module.Mod.Version),
-			slog.Int("dependenciesCount", len(dependencies)),
-			slog.Any("error", err))
-		return nil, fmt.Errorf("failed to create module nodes and relationships: %w", err)
+	retractions := make([]modfile.VersionInterval, 0, len(modFile.Retract))
+	for _, retract := range modFile.Retract {
+		retractions = append(retractions, retract.VersionInterval)
 	}
 
-	return dependsOn, nil
-}
+	// The module directive of a go.mod file has no version, so the version is the one that was fetched
+	node := moduleNode{
+		Version:     module.Version{Path: modFile.Module.Mod.Path, Version: modulePath.Version},
+		versionTime: moduleInfo.Time,
+		retractions: retractions,
+	}
 
-func extractOrg(modulePath string) string {
-	switch {
-	case strings.HasPrefix(modulePath, "github.com/"):
-		org := strings.Split(modulePath, "/")[1]
+	// Both the requested path and the path declared by the go.mod file must be consistent with the version
+	node.pathVersionMismatch = isPathVersionMismatch(modulePath) || isPathVersionMismatch(node.Version)
+	if node.pathVersionMismatch {
+		logger.Warn("module path inconsistent with its version", slog.String("declaredPath", modFile.Module.Mod.Path))
+	}
 
-		switch org {
-		case "pkg":
-			return "golang"
+	writeStart := time.Now()
+	defer func() {
+		writeDuration = time.Since(writeStart)
+	}()
 
-		default:
-			return org
+	if options.edges != nil {
+		if err := options.edges.write(node, dependencies); err != nil {
+			return nil, err
 		}
 
-	case strings.HasPrefix(modulePath, "google.golang.org/"):
-		return "google"
+		outcome = moduleWritten
+		return dependsOn, nil
+	}
+
+	if err := writeModule(ctx, driver, node, dependencies, options.writeOptions); err != nil {
+		return nil, err
+	}
+
+	outcome = moduleWritten
+	return dependsOn, nil
+}
+
+// toolModules returns the paths of the required modules providing the tools declared with the tool directive (go 1.24+).
+// A tool is provided by the required module with the longest path prefixing the tool package path.
+// go.mod files without a tool directive don't have any tool dependency.
+func toolModules(modFile *modfile.File) map[string]bool {
+	modules := make(map[string]bool, len(modFile.Tool))
+	for _, tool := range modFile.Tool {
+		var provider string
+		for _, require := range modFile.Require {
+			if tool.Path != require.Mod.Path && !strings.HasPrefix(tool.Path, require.Mod.Path+"/") {
+				continue
+			}
 
-	case strings.HasPrefix(modulePath, "golang.org/"),
-		strings.HasPrefix(modulePath, "github.com/pkg/"):
-		return "golang"
+			if len(require.Mod.Path) > len(provider) {
+				provider = require.Mod.Path
+			}
+		}
 
-	case strings.HasPrefix(modulePath, "k8s.io/"),
-		strings.HasPrefix(modulePath, "sigs.k8s.io/"):
-		return "kubernetes"
+		if provider != "" {
+			modules[provider] = true
+		}
+	}
 
-	case strings.HasPrefix(modulePath, "go.uber.org/"):
-		return "uber-go"
+	return modules
+}
 
-	case strings.HasPrefix(modulePath, "gorm.io/gorm"):
-		return "go-gorm"
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
 
-	case strings.HasPrefix(modulePath, "go.opentelemetry.io/"):
-		return "open-telemetry"
+	return false
+}
 
-	case strings.HasPrefix(modulePath, "go.mongodb.org/"):
-		return "mongodb"
+// parseGoVersion parses the major, minor and patch numbers of a go directive (eg. 1.21, 1.21.3 or 1.21rc1).
+// The missing numbers are considered 0 and the prerelease suffix is ignored.
+func parseGoVersion(version string) ([]int, bool) {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return nil, false
+	}
 
-	case strings.HasPrefix(modulePath, "go.etcd.io/"):
-		return "etcd-io"
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		// Only the last number can be followed by a prerelease suffix
+		digits := part
+		if i == len(parts)-1 {
+			if end := strings.IndexFunc(part, func(r rune) bool { return r < '0' || r > '9' }); end != -1 {
+				digits = part[:end]
+			}
+		}
 
-	default:
-		return ""
+		n, err := strconv.Atoi(digits)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		numbers[i] = n
 	}
+
+	return numbers, true
 }
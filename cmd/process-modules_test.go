@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseGoVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version  string
+		expected []int
+		ok       bool
+	}{
+		{version: "1.21", expected: []int{1, 21, 0}, ok: true},
+		{version: "1.21.0", expected: []int{1, 21, 0}, ok: true},
+		{version: "1.21.3", expected: []int{1, 21, 3}, ok: true},
+		{version: "1.21rc1", expected: []int{1, 21, 0}, ok: true},
+		{version: "1.21.0-rc.1", expected: []int{1, 21, 0}, ok: true},
+		{version: "1.9", expected: []int{1, 9, 0}, ok: true},
+		// A missing go directive is never parsed, it's equivalent to an empty version
+		{version: ""},
+		{version: "1"},
+		{version: "1."},
+		{version: "1.x"},
+		{version: "go1.21"},
+		{version: "1rc1.21"},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			actual, ok := parseGoVersion(tc.version)
+			if ok != tc.ok {
+				t.Fatalf("expected ok %t, got %t", tc.ok, ok)
+			}
+
+			if !slices.Equal(actual, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseGoVersionOrder(t *testing.T) {
+	// The parsed versions are compared with slices.Compare against the -min-go-version flag
+	versions := []string{"1.9", "1.20", "1.21rc1", "1.21", "1.21.3"}
+	for i := 1; i < len(versions); i++ {
+		previous, _ := parseGoVersion(versions[i-1])
+		current, _ := parseGoVersion(versions[i])
+		if slices.Compare(previous, current) > 0 {
+			t.Errorf("expected %s <= %s", versions[i-1], versions[i])
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/schollz/progressbar/v3"
+)
+
+func RecomputeOrgHostHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		orgRulesFile := command.Lookup[string](flagSet, "org-rules-file")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+
+		orgRules, err := loadOrgRules(orgRulesFile)
+		if err != nil {
+			slog.Error("failed to load org rules", slog.Any("error", err))
+			return 1
+		}
+
+		chNames, chErr := iterModuleNames(ctx, driver)
+
+		progress := progressbar.Default(-1, "recomputing org and host")
+
+		var nbChanged int64
+		if err := forEachBatch(chNames, batchSize, func(names []string) error {
+			modules := make([]map[string]any, 0, len(names))
+			for _, name := range names {
+				modules = append(modules, map[string]any{
+					"name": name,
+					"org":  extractOrg(orgRules, name),
+					"host": extractHost(name),
+				})
+			}
+
+			slog.Debug("updating org and host", slog.Int("count", len(modules)))
+			result, err := executeQuery(ctx, driver, `
+				UNWIND $modules AS module
+				MATCH (m:Module {name: module.name})
+				WHERE m.org IS NULL OR m.org <> module.org OR m.host IS NULL OR m.host <> module.host
+				SET m.org = module.org, m.host = module.host
+				RETURN count(m) AS changed
+			`, map[string]any{
+				"modules": modules,
+			}, neo4j.ExecuteQueryWithDatabase(""))
+			if err != nil {
+				return fmt.Errorf("failed to update org and host: %w", err)
+			}
+
+			changed, _, err := neo4j.GetRecordValue[int64](result.Records[0], "changed")
+			if err != nil {
+				return fmt.Errorf("failed to read changed count: %w", err)
+			}
+
+			nbChanged += changed
+			if err := progress.Add(len(names)); err != nil {
+				slog.Error("failed to update progress bar", slog.Any("error", err))
+			}
+
+			return nil
+		}); err != nil {
+			slog.Error("failed to recompute org and host", slog.Any("error", err))
+			return 1
+		}
+
+		if err := <-chErr; err != nil {
+			slog.Error("failed to list module names", slog.Any("error", err))
+			return 1
+		}
+
+		_ = progress.Finish()
+		fmt.Printf("%d module nodes changed\n", nbChanged)
+
+		return 0
+	}
+}
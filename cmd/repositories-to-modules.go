@@ -13,8 +13,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Thiht/go-command"
@@ -32,6 +34,16 @@ func RepositoriesToModulesHandler() command.Handler {
 	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
 		inputFile := command.Lookup[string](flagSet, "input-file")
 		outputFile := command.Lookup[string](flagSet, "output-file")
+		compress := command.Lookup[bool](flagSet, "gzip")
+		failOnError := command.Lookup[bool](flagSet, "fail-on-error")
+		minSuccessRatio := command.Lookup[float64](flagSet, "min-success-ratio")
+		moduleSubdir := command.Lookup[string](flagSet, "module-subdir")
+		excludedDirs := command.Lookup[[]string](flagSet, "exclude-dir")
+
+		if moduleSubdir != "" && !filepath.IsLocal(moduleSubdir) {
+			slog.Error("module subdirectory must be a relative path within the repository", slog.String("subdir", moduleSubdir))
+			return 1
+		}
 
 		slog.Debug("opening input file", slog.String("file", inputFile))
 		inputFileHandler, err := os.Open(inputFile)
@@ -62,9 +74,12 @@ func RepositoriesToModulesHandler() command.Handler {
 		modules := make([]module.Version, 0, len(repositories))
 		var mxModules sync.Mutex
 
-		g, gCtx := errgroup.WithContext(ctx)
+		// The group doesn't cancel the other repositories on failure, so that a single failure doesn't fail the whole run
+		var g errgroup.Group
 		sem := make(chan struct{}, parallel)
 
+		var nbSucceeded atomic.Int64
+
 		progress := progressbar.Default(int64(len(repositories)))
 		for _, repoURL := range repositories {
 			sem <- struct{}{}
@@ -75,8 +90,6 @@ func RepositoriesToModulesHandler() command.Handler {
 					<-sem
 				}()
 
-				ctx := gCtx
-
 				repoName := repoURL[strings.LastIndex(repoURL, "/")+1:]
 				repoURL += ".git"
 				repoURLHash := fmt.Sprintf("%x", sha256.Sum256([]byte(repoURL)))
@@ -119,8 +132,20 @@ func RepositoriesToModulesHandler() command.Handler {
 					}
 				}()
 
-				if err := filepath.WalkDir(clonePath, func(path string, info os.DirEntry, _ error) error {
+				walkPath := filepath.Join(clonePath, moduleSubdir)
+				if _, err := os.Stat(walkPath); err != nil {
+					logger.Warn("module subdirectory not found in repository", slog.String("path", walkPath), slog.Any("error", err))
+					nbSucceeded.Add(1)
+					return nil
+				}
+
+				if err := filepath.WalkDir(walkPath, func(path string, info os.DirEntry, _ error) error {
 					if info.Type().IsDir() {
+						if path != walkPath && slices.Contains(excludedDirs, info.Name()) {
+							logger.Debug("skipping excluded directory", slog.String("path", path))
+							return filepath.SkipDir
+						}
+
 						return nil
 					}
 
@@ -170,18 +195,35 @@ func RepositoriesToModulesHandler() command.Handler {
 					return fmt.Errorf("failed to walk repository: %w", err)
 				}
 
+				nbSucceeded.Add(1)
+
 				return nil
 			})
 		}
 
 		if err := g.Wait(); err != nil {
 			slog.Error("failed to list some modules", slog.Any("error", err))
+			if failOnError {
+				slog.Error("not writing output file because some repositories failed", slog.String("file", outputFile))
+				return 1
+			}
 		}
 
 		close(sem)
 
+		if len(repositories) > 0 {
+			successRatio := float64(nbSucceeded.Load()) / float64(len(repositories))
+			if successRatio < minSuccessRatio {
+				slog.Error("not writing output file because too many repositories failed",
+					slog.String("file", outputFile),
+					slog.Float64("successRatio", successRatio),
+					slog.Float64("minSuccessRatio", minSuccessRatio))
+				return 1
+			}
+		}
+
 		slog.Debug("opening output file", slog.String("file", outputFile))
-		outputFileHandler, err := os.Create(outputFile)
+		outputFileHandler, err := createOutputFile(outputFile, compress)
 		if err != nil {
 			slog.Error("failed to open output file", slog.String("file", outputFile), slog.Any("error", err))
 			return 1
@@ -196,6 +238,11 @@ func RepositoriesToModulesHandler() command.Handler {
 			}
 		}
 
+		if err := outputFileHandler.Close(); err != nil {
+			slog.Error("failed to close output file", slog.String("file", outputFile), slog.Any("error", err))
+			return 1
+		}
+
 		return 0
 	}
 }
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/mod/module"
+)
+
+// listProcessedModules returns the dependencies stored in the graph for the modules already flagged as processed, by module path.
+// A module without version matches any of its processed versions.
+func listProcessedModules(ctx context.Context, driver neo4j.DriverWithContext, modules []module.Version, batchSize int) (map[string][]module.Version, error) {
+	processed := map[string][]module.Version{}
+
+	for start := 0; start < len(modules); start += batchSize {
+		batch := make([]map[string]any, 0, batchSize)
+		for _, m := range modules[start:min(start+batchSize, len(modules))] {
+			batch = append(batch, map[string]any{"name": m.Path, "version": m.Version})
+		}
+
+		slog.Debug("listing processed modules", slog.Int("count", len(batch)))
+		result, err := executeQuery(ctx, driver, `
+			UNWIND $modules AS module
+			MATCH (m:Module {name: module.name})
+			WHERE m.processed AND (module.version = "" OR m.version = module.version)
+			RETURN m.name AS name, [(m)-[:DEPENDS_ON|TOOL_DEPENDS_ON]->(d:Module) | [d.name, d.version]] AS dependencies
+		`, map[string]any{
+			"modules": batch,
+		}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+		if err != nil {
+			return nil, fmt.Errorf("failed to list processed modules: %w", err)
+		}
+
+		for _, record := range result.Records {
+			name, _, _ := neo4j.GetRecordValue[string](record, "name")
+			dependencies, _, _ := neo4j.GetRecordValue[[]any](record, "dependencies")
+
+			if _, ok := processed[name]; !ok {
+				processed[name] = nil
+			}
+
+			for _, dependency := range dependencies {
+				pair, _ := dependency.([]any)
+				if len(pair) != 2 {
+					continue
+				}
+
+				path, _ := pair[0].(string)
+				version, _ := pair[1].(string)
+				processed[name] = append(processed[name], module.Version{Path: path, Version: version})
+			}
+		}
+	}
+
+	return processed, nil
+}
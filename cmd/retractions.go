@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/semver"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+)
+
+// RetractionsHandler reports the module versions of the graph retracted by their own module,
+// or the modules depending on them.
+func RetractionsHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		mode := command.Lookup[string](flagSet, "mode")
+		format := command.Lookup[string](flagSet, "format")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+
+		if mode != "versions" && mode != "dependents" {
+			slog.Error("invalid \"mode\", expected versions or dependents", slog.String("mode", mode))
+			return 1
+		}
+
+		retractions, err := listRetractions(ctx, driver)
+		if err != nil {
+			slog.Error("failed to list retractions", slog.Any("error", err))
+			return 1
+		}
+
+		names := make([]string, 0, len(retractions))
+		for name := range retractions {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		var retracted []module.Version
+		for start := 0; start < len(names); start += batchSize {
+			batch := names[start:min(start+batchSize, len(names))]
+
+			slog.Debug("listing versions of modules with retractions", slog.Int("count", len(batch)))
+			result, err := executeQuery(ctx, driver, `
+				UNWIND $names AS name
+				MATCH (m:Module {name: name})
+				RETURN m.name AS name, m.version AS version
+				ORDER BY name, version
+			`, map[string]any{
+				"names": batch,
+			}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+			if err != nil {
+				slog.Error("failed to list versions of modules with retractions", slog.Any("error", err))
+				return 1
+			}
+
+			for _, record := range result.Records {
+				name, _, _ := neo4j.GetRecordValue[string](record, "name")
+				version, _, _ := neo4j.GetRecordValue[string](record, "version")
+
+				if isRetracted(version, retractions[name]) {
+					retracted = append(retracted, module.Version{Path: name, Version: version})
+				}
+			}
+		}
+
+		if mode == "versions" {
+			rows := make([][]string, 0, len(retracted))
+			for _, m := range retracted {
+				rows = append(rows, []string{m.Path, m.Version})
+			}
+
+			if err := writeRows(os.Stdout, format, []string{"module", "version"}, rows); err != nil {
+				slog.Error("failed to write retracted versions", slog.Any("error", err))
+				return 1
+			}
+
+			return 0
+		}
+
+		var rows [][]string
+		for start := 0; start < len(retracted); start += batchSize {
+			batch := make([]map[string]any, 0, batchSize)
+			for _, m := range retracted[start:min(start+batchSize, len(retracted))] {
+				batch = append(batch, map[string]any{"name": m.Path, "version": m.Version})
+			}
+
+			slog.Debug("listing dependents of retracted versions", slog.Int("count", len(batch)))
+			result, err := executeQuery(ctx, driver, `
+				UNWIND $modules AS module
+				MATCH (dependent:Module)-[:DEPENDS_ON]->(dependency:Module {name: module.name, version: module.version})
+				RETURN dependent.name AS dependent, dependent.version AS dependentVersion, dependency.name AS dependency, dependency.version AS dependencyVersion
+				ORDER BY dependent, dependentVersion, dependency
+			`, map[string]any{
+				"modules": batch,
+			}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+			if err != nil {
+				slog.Error("failed to list dependents of retracted versions", slog.Any("error", err))
+				return 1
+			}
+
+			for _, record := range result.Records {
+				dependent, _, _ := neo4j.GetRecordValue[string](record, "dependent")
+				dependentVersion, _, _ := neo4j.GetRecordValue[string](record, "dependentVersion")
+				dependency, _, _ := neo4j.GetRecordValue[string](record, "dependency")
+				dependencyVersion, _, _ := neo4j.GetRecordValue[string](record, "dependencyVersion")
+
+				rows = append(rows, []string{dependent, dependentVersion, dependency, dependencyVersion})
+			}
+		}
+
+		if err := writeRows(os.Stdout, format, []string{"dependent", "dependentVersion", "dependency", "retractedVersion"}, rows); err != nil {
+			slog.Error("failed to write dependents of retracted versions", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
+
+// listRetractions returns the version intervals retracted by any version of each module.
+func listRetractions(ctx context.Context, driver neo4j.DriverWithContext) (map[string][]modfile.VersionInterval, error) {
+	slog.Debug("listing retractions")
+	result, err := executeQuery(ctx, driver, `
+		MATCH (m:Module)
+		WHERE size(m.retractLow) > 0
+		RETURN m.name AS name, m.retractLow AS low, m.retractHigh AS high
+	`, nil, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retractions: %w", err)
+	}
+
+	retractions := map[string][]modfile.VersionInterval{}
+	for _, record := range result.Records {
+		name, _, _ := neo4j.GetRecordValue[string](record, "name")
+		lows, _, _ := neo4j.GetRecordValue[[]any](record, "low")
+		highs, _, _ := neo4j.GetRecordValue[[]any](record, "high")
+
+		for i := range min(len(lows), len(highs)) {
+			low, _ := lows[i].(string)
+			high, _ := highs[i].(string)
+
+			retractions[name] = append(retractions[name], modfile.VersionInterval{Low: low, High: high})
+		}
+	}
+
+	return retractions, nil
+}
+
+func isRetracted(version string, retractions []modfile.VersionInterval) bool {
+	parsed, err := semver.Parse(version)
+	if err != nil {
+		return false
+	}
+
+	for _, retraction := range retractions {
+		low, err := semver.Parse(retraction.Low)
+		if err != nil {
+			continue
+		}
+
+		high, err := semver.Parse(retraction.High)
+		if err != nil {
+			continue
+		}
+
+		if semver.Compare(low, parsed) <= 0 && semver.Compare(parsed, high) <= 0 {
+			return true
+		}
+	}
+
+	return false
+}
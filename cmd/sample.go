@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+)
+
+// sampler keeps each module with a given probability, deterministically from a seed and the module path,
+// so that runs with the same seed process the same modules.
+type sampler struct {
+	rate float64
+	seed int64
+}
+
+func newSampler(rate float64, seed int64) (sampler, error) {
+	if rate < 0 || rate > 1 {
+		return sampler{}, fmt.Errorf("invalid sample rate %v, expected a value between 0 and 1", rate)
+	}
+
+	return sampler{rate: rate, seed: seed}, nil
+}
+
+func (s sampler) keep(modulePath string) bool {
+	if s.rate >= 1 {
+		return true
+	}
+
+	h := fnv.New64a()
+	_ = binary.Write(h, binary.LittleEndian, s.seed)
+	_, _ = h.Write([]byte(modulePath))
+
+	// The 53 high bits of the hash give a uniform float in [0, 1)
+	return float64(h.Sum64()>>11)/(1<<53) < s.rate
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/Thiht/go-command"
+	"github.com/Thiht/go-stats/goproxy"
+)
+
+// StreamIndexHandler dumps the index as it is listed, without deduplication, so that its memory usage doesn't grow with the index.
+func StreamIndexHandler(goProxyClient goproxy.Client) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		since, err := time.Parse(time.RFC3339, command.Lookup[string](flagSet, "since"))
+		if err != nil {
+			slog.Error("failed to parse \"since\"", slog.String("since", command.Lookup[string](flagSet, "since")), slog.Any("error", err))
+			return 1
+		}
+
+		until, err := time.Parse(time.RFC3339, command.Lookup[string](flagSet, "until"))
+		if err != nil {
+			slog.Error("failed to parse \"until\"", slog.String("until", command.Lookup[string](flagSet, "until")), slog.Any("error", err))
+			return 1
+		}
+
+		outputFile := command.Lookup[string](flagSet, "output-file")
+		compress := command.Lookup[bool](flagSet, "gzip")
+		format := command.Lookup[string](flagSet, "format")
+
+		if format != "csv" && format != "jsonl" {
+			slog.Error("invalid \"format\", expected csv or jsonl", slog.String("format", format))
+			return 1
+		}
+
+		slog.Debug("opening output file", slog.String("file", outputFile))
+		outputFileHandler, err := createOutputFile(outputFile, compress)
+		if err != nil {
+			slog.Error("failed to open output file", slog.String("file", outputFile), slog.Any("error", err))
+			return 1
+		}
+		defer outputFileHandler.Close()
+
+		if err := StreamIndex(ctx, goProxyClient, since, until, outputFileHandler, format); err != nil {
+			slog.Error("failed to stream index", slog.Any("error", err))
+			return 1
+		}
+
+		if err := outputFileHandler.Close(); err != nil {
+			slog.Error("failed to close output file", slog.String("file", outputFile), slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
+
+// StreamIndex writes the index entries from since to until to w as they are listed, either as csv or as jsonl.
+func StreamIndex(ctx context.Context, goProxyClient goproxy.Client, since, until time.Time, w io.Writer, format string) error {
+	var write func(goproxy.Index) error
+
+	switch format {
+	case "csv":
+		writer := csv.NewWriter(w)
+		if err := writer.Write([]string{"path", "version", "timestamp"}); err != nil {
+			return fmt.Errorf("failed to write header: %w", err)
+		}
+
+		// Flushing every entry keeps the output usable when the listing is interrupted
+		write = func(i goproxy.Index) error {
+			if err := writer.Write([]string{i.Path, i.Version, i.Timestamp.Format(time.RFC3339Nano)}); err != nil {
+				return err
+			}
+
+			writer.Flush()
+			return writer.Error()
+		}
+
+	case "jsonl":
+		encoder := json.NewEncoder(w)
+		write = func(i goproxy.Index) error {
+			return encoder.Encode(i)
+		}
+
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+
+	return goproxy.IterIndex(ctx, goProxyClient, since, until, func(i goproxy.Index) error {
+		if err := write(i); err != nil {
+			return fmt.Errorf("failed to write index entry: %w", err)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// The histogram buckets grow exponentially from histogramMinDuration, with histogramBucketsPerDoubling buckets
+	// each time the duration doubles, so that the percentiles are within 20% of the actual durations up to about 2 hours.
+	histogramMinDuration        = 100 * time.Microsecond
+	histogramBucketsPerDoubling = 4
+	histogramBuckets            = 26 * histogramBucketsPerDoubling
+)
+
+// durationHistogram records durations concurrently in exponential buckets, so that its memory is bounded
+// whatever the number of durations. The percentiles are approximated by the upper bound of their bucket.
+type durationHistogram struct {
+	mx      sync.Mutex
+	buckets [histogramBuckets]int64
+	count   int64
+	sum     time.Duration
+	max     time.Duration
+}
+
+func (h *durationHistogram) record(d time.Duration) {
+	bucket := 0
+	if d > histogramMinDuration {
+		bucket = min(int(math.Ceil(histogramBucketsPerDoubling*math.Log2(float64(d)/float64(histogramMinDuration)))), histogramBuckets-1)
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.buckets[bucket]++
+	h.count++
+	h.sum += d
+	h.max = max(h.max, d)
+}
+
+// bucketUpperBound returns the highest duration recorded in a bucket.
+func bucketUpperBound(bucket int) time.Duration {
+	return time.Duration(float64(histogramMinDuration) * math.Exp2(float64(bucket)/histogramBucketsPerDoubling))
+}
+
+func (h *durationHistogram) total() int64 {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	return h.count
+}
+
+func (h *durationHistogram) average() time.Duration {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+
+	return h.sum / time.Duration(h.count)
+}
+
+// percentile returns the duration below which p percent of the recorded durations fall, using the nearest rank.
+func (h *durationHistogram) percentile(p float64) time.Duration {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := min(max(int64(math.Ceil(p/100*float64(h.count))), 1), h.count)
+
+	var cumulated int64
+	for bucket, count := range h.buckets {
+		cumulated += count
+		if cumulated >= rank {
+			// The last buckets are wider than the durations actually recorded
+			return min(bucketUpperBound(bucket), h.max)
+		}
+	}
+
+	return h.max
+}
+
+type moduleOutcome int
+
+const (
+	moduleFailed moduleOutcome = iota
+	moduleSkipped
+	moduleWritten
+)
+
+// moduleTimings are the time spent per module fetching it from the proxy and writing it to Neo4j (or to the edges output),
+// and the number of modules by outcome.
+type moduleTimings struct {
+	fetch durationHistogram
+	write durationHistogram
+	total durationHistogram
+
+	mx       sync.Mutex
+	outcomes map[moduleOutcome]int64
+}
+
+func newModuleTimings() *moduleTimings {
+	return &moduleTimings{
+		outcomes: map[moduleOutcome]int64{},
+	}
+}
+
+// record adds the durations of a processed module. The write duration is only recorded for the modules that were written,
+// so that the modules skipped or failed after fetching don't skew the write times.
+func (t *moduleTimings) record(fetch, write time.Duration, outcome moduleOutcome) {
+	t.fetch.record(fetch)
+	if outcome == moduleWritten {
+		t.write.record(write)
+	}
+	t.total.record(fetch + write)
+
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	t.outcomes[outcome]++
+}
+
+// print writes the number of modules by outcome, then the average and percentiles of the fetch, write and total durations.
+func (t *moduleTimings) print(w io.Writer, elapsed time.Duration) error {
+	t.mx.Lock()
+	_, err := fmt.Fprintf(w, "%d modules written, %d skipped, %d failed in %s\n", t.outcomes[moduleWritten], t.outcomes[moduleSkipped], t.outcomes[moduleFailed], elapsed.Round(time.Second))
+	t.mx.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to write outcomes: %w", err)
+	}
+
+	rows := make([][]string, 0, 3)
+	for _, stage := range []struct {
+		name      string
+		histogram *durationHistogram
+	}{
+		{"fetch", &t.fetch},
+		{"write", &t.write},
+		{"total", &t.total},
+	} {
+		rows = append(rows, []string{
+			stage.name,
+			fmt.Sprint(stage.histogram.total()),
+			formatDuration(stage.histogram.average()),
+			formatDuration(stage.histogram.percentile(50)),
+			formatDuration(stage.histogram.percentile(95)),
+			formatDuration(stage.histogram.percentile(99)),
+		})
+	}
+
+	return writeRows(w, "table", []string{"stage", "modules", "avg", "p50", "p95", "p99"}, rows)
+}
+
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Millisecond / 10).String()
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// TopDependedHandler lists the most depended on modules from a view cached in :TopModule nodes,
+// as ranking them on the whole graph is too slow for interactive queries.
+// The :TopModule nodes are scoped by the node label, so that each graph of the database has its own view.
+func TopDependedHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		refresh := command.Lookup[bool](flagSet, "refresh")
+		top := command.Lookup[int](flagSet, "top")
+		format := command.Lookup[string](flagSet, "format")
+
+		rows, err := readTopModules(ctx, driver)
+		if err != nil {
+			slog.Error("failed to read top modules", slog.Any("error", err))
+			return 1
+		}
+
+		if refresh || len(rows) == 0 {
+			if err := refreshTopModules(ctx, driver, top); err != nil {
+				slog.Error("failed to refresh top modules", slog.Any("error", err))
+				return 1
+			}
+
+			rows, err = readTopModules(ctx, driver)
+			if err != nil {
+				slog.Error("failed to read top modules", slog.Any("error", err))
+				return 1
+			}
+		}
+
+		if err := writeRows(os.Stdout, format, []string{"rank", "module", "dependents", "computedAt"}, rows); err != nil {
+			slog.Error("failed to write top modules", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
+
+// refreshTopModules replaces the cached view with the top modules by number of distinct dependent modules.
+func refreshTopModules(ctx context.Context, driver neo4j.DriverWithContext, top int) error {
+	slog.Debug("refreshing top modules", slog.Int("top", top))
+	if _, err := executeQuery(ctx, driver, `
+		MATCH (dependent:Module)-[:DEPENDS_ON]->(m:Module)
+		WITH m.name AS name, count(DISTINCT dependent.name) AS dependents
+		ORDER BY dependents DESC, name
+		LIMIT $top
+		WITH collect({name: name, dependents: dependents}) AS modules
+		CALL {
+			MATCH (t:TopModule {label: $label})
+			DETACH DELETE t
+		}
+		UNWIND range(0, size(modules) - 1) AS i
+		CREATE (:TopModule {label: $label, rank: i + 1, name: modules[i].name, dependents: modules[i].dependents, computedAt: $computedAt})
+	`, map[string]any{
+		"top":        top,
+		"label":      nodeLabel,
+		"computedAt": time.Now().UTC(),
+	}, neo4j.ExecuteQueryWithDatabase("")); err != nil {
+		return fmt.Errorf("failed to write top modules: %w", err)
+	}
+
+	return nil
+}
+
+func readTopModules(ctx context.Context, driver neo4j.DriverWithContext) ([][]string, error) {
+	result, err := executeQuery(ctx, driver, `
+		MATCH (t:TopModule {label: $label})
+		RETURN t.rank AS rank, t.name AS name, t.dependents AS dependents, t.computedAt AS computedAt
+		ORDER BY rank
+	`, map[string]any{
+		"label": nodeLabel,
+	}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list top modules: %w", err)
+	}
+
+	rows := make([][]string, 0, len(result.Records))
+	for _, record := range result.Records {
+		rank, _, _ := neo4j.GetRecordValue[int64](record, "rank")
+		name, _, _ := neo4j.GetRecordValue[string](record, "name")
+		dependents, _, _ := neo4j.GetRecordValue[int64](record, "dependents")
+		computedAt, _, _ := neo4j.GetRecordValue[time.Time](record, "computedAt")
+
+		rows = append(rows, []string{strconv.FormatInt(rank, 10), name, strconv.FormatInt(dependents, 10), computedAt.Format(time.RFC3339)})
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/mod/module"
+)
+
+type treeNode struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	// Visited marks a module whose dependencies are already listed elsewhere in the tree, to stop at cycles and shared dependencies.
+	Visited bool `json:"visited,omitempty"`
+
+	// Truncated marks a module whose dependencies weren't walked because it is at the maximum depth.
+	Truncated bool `json:"truncated,omitempty"`
+
+	Dependencies []*treeNode `json:"dependencies"`
+}
+
+// TreeHandler writes the transitive dependencies of a root module as a nested JSON tree, eg. for visualization tools.
+func TreeHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		modulePath := command.Lookup[string](flagSet, "module")
+		version := command.Lookup[string](flagSet, "module-version")
+		maxDepth := command.Lookup[int](flagSet, "max-depth")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+
+		if modulePath == "" {
+			slog.Error("\"module\" is required")
+			return 1
+		}
+
+		root, err := resolveRootModule(ctx, driver, modulePath, version)
+		if err != nil {
+			slog.Error("failed to resolve root module", slog.Any("error", err))
+			return 1
+		}
+
+		walk, err := walkDependencies(ctx, driver, root, maxDepth, batchSize)
+		if err != nil {
+			slog.Error("failed to walk dependencies", slog.Any("error", err))
+			return 1
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(walk.tree(root)); err != nil {
+			slog.Error("failed to write dependency tree", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
+
+// tree builds the dependency tree of a walked module. The modules are only expanded under the parent through which
+// the breadth-first walk first reached them, so that each module appears at its shallowest depth and the tree doesn't go
+// deeper than the walk. Their other occurrences are marked as visited.
+func (w *dependencyWalk) tree(m module.Version) *treeNode {
+	node := &treeNode{
+		Name:         m.Path,
+		Version:      m.Version,
+		Dependencies: []*treeNode{},
+	}
+
+	dependencies, ok := w.dependencies[m]
+	if !ok {
+		node.Truncated = true
+		return node
+	}
+
+	for _, dependency := range dependencies {
+		if parent, ok := w.parents[dependency]; !ok || parent != m {
+			node.Dependencies = append(node.Dependencies, &treeNode{
+				Name:         dependency.Path,
+				Version:      dependency.Version,
+				Visited:      true,
+				Dependencies: []*treeNode{},
+			})
+			continue
+		}
+
+		node.Dependencies = append(node.Dependencies, w.tree(dependency))
+	}
+
+	return node
+}
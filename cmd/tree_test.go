@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"golang.org/x/mod/module"
+)
+
+func TestDependencyWalkTree(t *testing.T) {
+	root := module.Version{Path: "example.com/root", Version: "v1.0.0"}
+	a := module.Version{Path: "example.com/a", Version: "v1.0.0"}
+	b := module.Version{Path: "example.com/b", Version: "v1.0.0"}
+	c := module.Version{Path: "example.com/c", Version: "v1.0.0"}
+
+	// Diamond root -> a -> b and root -> b, with b -> c -> root, walked with a maximum depth of 2:
+	// the dependencies of c, at depth 2, aren't walked
+	walk := &dependencyWalk{
+		root:  root,
+		order: []module.Version{root, a, b, c},
+		dependencies: map[module.Version][]module.Version{
+			root: {a, b},
+			a:    {b},
+			b:    {c},
+		},
+		parents: map[module.Version]module.Version{
+			a: root,
+			b: root,
+			c: b,
+		},
+	}
+
+	actual, err := json.Marshal(walk.tree(root))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// b is expanded under root rather than under a, so that c isn't deeper than the maximum depth
+	expected := `{"name":"example.com/root","version":"v1.0.0","dependencies":[` +
+		`{"name":"example.com/a","version":"v1.0.0","dependencies":[` +
+		`{"name":"example.com/b","version":"v1.0.0","visited":true,"dependencies":[]}]},` +
+		`{"name":"example.com/b","version":"v1.0.0","dependencies":[` +
+		`{"name":"example.com/c","version":"v1.0.0","truncated":true,"dependencies":[]}]}]}`
+
+	if string(actual) != expected {
+		t.Errorf("expected %s, got %s", expected, actual)
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/mod/module"
+)
+
+// VersionConflictsHandler lists the dependencies reached in several versions from a root module (diamond dependencies),
+// with the shortest path introducing each version.
+func VersionConflictsHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		modulePath := command.Lookup[string](flagSet, "module")
+		version := command.Lookup[string](flagSet, "module-version")
+		maxDepth := command.Lookup[int](flagSet, "max-depth")
+		batchSize := command.Lookup[int](flagSet, "batch-size")
+		format := command.Lookup[string](flagSet, "format")
+
+		if modulePath == "" {
+			slog.Error("\"module\" is required")
+			return 1
+		}
+
+		root, err := resolveRootModule(ctx, driver, modulePath, version)
+		if err != nil {
+			slog.Error("failed to resolve root module", slog.Any("error", err))
+			return 1
+		}
+
+		walk, err := walkDependencies(ctx, driver, root, maxDepth, batchSize)
+		if err != nil {
+			slog.Error("failed to walk dependencies", slog.Any("error", err))
+			return 1
+		}
+
+		versions := map[string][]module.Version{}
+		for _, m := range walk.order[1:] {
+			versions[m.Path] = append(versions[m.Path], m)
+		}
+
+		var rows [][]string
+		for _, modules := range versions {
+			if len(modules) < 2 {
+				continue
+			}
+
+			for _, m := range modules {
+				path := walk.path(m)
+				steps := make([]string, 0, len(path))
+				for _, step := range path {
+					steps = append(steps, step.String())
+				}
+
+				rows = append(rows, []string{m.Path, m.Version, strings.Join(steps, " -> ")})
+			}
+		}
+
+		slices.SortFunc(rows, func(a, b []string) int {
+			if c := strings.Compare(a[0], b[0]); c != 0 {
+				return c
+			}
+
+			return strings.Compare(a[1], b[1])
+		})
+
+		if err := writeRows(os.Stdout, format, []string{"dependency", "version", "path"}, rows); err != nil {
+			slog.Error("failed to write version conflicts", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
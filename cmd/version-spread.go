@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/Thiht/go-command"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// VersionSpreadHandler lists the dependencies depended upon in the most distinct versions across the graph.
+func VersionSpreadHandler(driver neo4j.DriverWithContext) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, _ []string) int {
+		top := command.Lookup[int](flagSet, "top")
+		format := command.Lookup[string](flagSet, "format")
+
+		limit := ""
+		if top > 0 {
+			limit = "LIMIT " + strconv.Itoa(top)
+		}
+
+		slog.Debug("computing dependency version spread")
+		result, err := executeQuery(ctx, driver, `
+			MATCH (dependent:Module)-[:DEPENDS_ON]->(d:Module)
+			RETURN d.name AS name, count(DISTINCT d.version) AS versions, count(DISTINCT dependent) AS dependents
+			ORDER BY versions DESC, name
+			`+limit, nil, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+		if err != nil {
+			slog.Error("failed to compute dependency version spread", slog.Any("error", err))
+			return 1
+		}
+
+		rows := make([][]string, 0, len(result.Records))
+		for _, record := range result.Records {
+			name, _, _ := neo4j.GetRecordValue[string](record, "name")
+			versions, _, _ := neo4j.GetRecordValue[int64](record, "versions")
+			dependents, _, _ := neo4j.GetRecordValue[int64](record, "dependents")
+
+			rows = append(rows, []string{name, strconv.FormatInt(versions, 10), strconv.FormatInt(dependents, 10)})
+		}
+
+		if err := writeRows(os.Stdout, format, []string{"dependency", "versions", "dependents"}, rows); err != nil {
+			slog.Error("failed to write dependency version spread", slog.Any("error", err))
+			return 1
+		}
+
+		return 0
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Thiht/go-command"
+)
+
+type BuildInfo struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+}
+
+func VersionHandler(info BuildInfo) command.Handler {
+	return func(_ context.Context, _ *flag.FlagSet, _ []string) int {
+		PrintVersion(os.Stdout, info)
+		return 0
+	}
+}
+
+func PrintVersion(w io.Writer, info BuildInfo) {
+	fmt.Fprintf(w, "version:    %s\n", valueOrUnknown(info.Version))
+	fmt.Fprintf(w, "commit:     %s\n", valueOrUnknown(info.Commit))
+	fmt.Fprintf(w, "build date: %s\n", valueOrUnknown(info.Date))
+	fmt.Fprintf(w, "go version: %s\n", valueOrUnknown(info.GoVersion))
+}
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+
+	return value
+}
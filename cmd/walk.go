@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"golang.org/x/mod/module"
+)
+
+// dependencyWalk is the transitive closure of the dependencies of a root module, as found by walkDependencies.
+type dependencyWalk struct {
+	root module.Version
+
+	// order lists the visited modules in breadth-first order, starting with the root.
+	order []module.Version
+
+	// dependencies are the direct dependencies of the visited modules whose dependencies were fetched,
+	// ie. the ones above the maximum depth.
+	dependencies map[module.Version][]module.Version
+
+	// parents are the modules through which each module was first reached, so that following them
+	// gives a shortest path from the root.
+	parents map[module.Version]module.Version
+}
+
+// walkDependencies walks the DEPENDS_ON relationships breadth-first from a root module, up to maxDepth levels (0 for no limit).
+// The dependencies of each level are fetched in batches rather than one query per module.
+func walkDependencies(ctx context.Context, driver neo4j.DriverWithContext, root module.Version, maxDepth, batchSize int) (*dependencyWalk, error) {
+	walk := &dependencyWalk{
+		root:         root,
+		order:        []module.Version{root},
+		dependencies: map[module.Version][]module.Version{},
+		parents:      map[module.Version]module.Version{},
+	}
+
+	visited := map[module.Version]struct{}{root: {}}
+	frontier := []module.Version{root}
+	for depth := 0; len(frontier) > 0 && (maxDepth <= 0 || depth < maxDepth); depth++ {
+		var next []module.Version
+
+		for start := 0; start < len(frontier); start += batchSize {
+			batch := frontier[start:min(start+batchSize, len(frontier))]
+
+			modules := make([]map[string]any, 0, len(batch))
+			for _, m := range batch {
+				modules = append(modules, map[string]any{"name": m.Path, "version": m.Version})
+				walk.dependencies[m] = []module.Version{}
+			}
+
+			slog.Debug("listing dependencies", slog.Int("depth", depth), slog.Int("count", len(modules)))
+			result, err := executeQuery(ctx, driver, `
+				UNWIND $modules AS module
+				MATCH (m:Module {name: module.name, version: module.version})-[:DEPENDS_ON]->(d:Module)
+				RETURN m.name AS name, m.version AS version, d.name AS dependency, d.version AS dependencyVersion
+				ORDER BY name, version, dependency, dependencyVersion
+			`, map[string]any{
+				"modules": modules,
+			}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+			if err != nil {
+				return nil, fmt.Errorf("failed to list dependencies: %w", err)
+			}
+
+			for _, record := range result.Records {
+				name, _, _ := neo4j.GetRecordValue[string](record, "name")
+				version, _, _ := neo4j.GetRecordValue[string](record, "version")
+				dependencyName, _, _ := neo4j.GetRecordValue[string](record, "dependency")
+				dependencyVersion, _, _ := neo4j.GetRecordValue[string](record, "dependencyVersion")
+
+				m := module.Version{Path: name, Version: version}
+				dependency := module.Version{Path: dependencyName, Version: dependencyVersion}
+				walk.dependencies[m] = append(walk.dependencies[m], dependency)
+
+				if _, ok := visited[dependency]; ok {
+					continue
+				}
+				visited[dependency] = struct{}{}
+
+				walk.parents[dependency] = m
+				walk.order = append(walk.order, dependency)
+				next = append(next, dependency)
+			}
+		}
+
+		frontier = next
+	}
+
+	return walk, nil
+}
+
+// path returns the shortest path from the root to a visited module, both included.
+func (w *dependencyWalk) path(m module.Version) []module.Version {
+	path := []module.Version{m}
+	for m != w.root {
+		m = w.parents[m]
+		path = append(path, m)
+	}
+
+	slices.Reverse(path)
+
+	return path
+}
+
+// resolveRootModule returns the given version of a module, or its highest version in the graph if version is empty.
+func resolveRootModule(ctx context.Context, driver neo4j.DriverWithContext, modulePath, version string) (module.Version, error) {
+	if version != "" {
+		return module.Version{Path: modulePath, Version: version}, nil
+	}
+
+	result, err := executeQuery(ctx, driver, `
+		MATCH (m:Module {name: $name})
+		RETURN m.version AS version
+	`, map[string]any{
+		"name": modulePath,
+	}, neo4j.ExecuteQueryWithDatabase(""), neo4j.ExecuteQueryWithReadersRouting())
+	if err != nil {
+		return module.Version{}, fmt.Errorf("failed to list module versions: %w", err)
+	}
+
+	for _, record := range result.Records {
+		v, _, _ := neo4j.GetRecordValue[string](record, "version")
+		version = highestVersion(version, v)
+	}
+
+	if version == "" {
+		return module.Version{}, fmt.Errorf("module %s not found in the graph", modulePath)
+	}
+
+	return module.Version{Path: modulePath, Version: version}, nil
+}
@@ -0,0 +1,138 @@
+package goproxy
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker is shared by all the requests of a client.
+// It opens after threshold consecutive failures within window, fails fast during cooldown,
+// then lets a single request through to test whether the proxy recovered.
+type circuitBreaker struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	mx           sync.Mutex
+	state        circuitState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probing      bool
+
+	// generation is incremented on each state change, so that the outcomes of the requests allowed before it are ignored.
+	generation uint64
+}
+
+// WithCircuitBreaker makes the requests fail fast with ErrCircuitOpen for cooldown once threshold consecutive requests
+// failed within window, to stop hammering a proxy that is down. A threshold of 0 or less disables it.
+func WithCircuitBreaker(threshold int, window, cooldown time.Duration) Option {
+	return func(c *client) {
+		if threshold <= 0 {
+			c.circuitBreaker = nil
+			return
+		}
+
+		c.circuitBreaker = &circuitBreaker{
+			threshold: threshold,
+			window:    window,
+			cooldown:  cooldown,
+		}
+	}
+}
+
+// allow returns the generation of the circuit breaker to pass to record or abort once the request is done,
+// or ErrCircuitOpen if the request must fail fast.
+func (cb *circuitBreaker) allow() (uint64, error) {
+	cb.mx.Lock()
+	defer cb.mx.Unlock()
+
+	switch cb.state {
+	case circuitOpen:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return 0, ErrCircuitOpen
+		}
+
+		cb.setState(circuitHalfOpen)
+		cb.probing = true
+		return cb.generation, nil
+
+	case circuitHalfOpen:
+		if cb.probing {
+			return 0, ErrCircuitOpen
+		}
+
+		cb.probing = true
+		return cb.generation, nil
+
+	default:
+		return cb.generation, nil
+	}
+}
+
+func (cb *circuitBreaker) setState(state circuitState) {
+	cb.state = state
+	cb.generation++
+}
+
+// record counts the outcome of a request allowed in the given generation.
+// The requests in flight when the state changed don't count, eg. a late success can't close an open circuit.
+func (cb *circuitBreaker) record(generation uint64, success bool) {
+	cb.mx.Lock()
+	defer cb.mx.Unlock()
+
+	if generation != cb.generation {
+		return
+	}
+
+	if cb.state == circuitHalfOpen {
+		cb.probing = false
+		if success {
+			cb.setState(circuitClosed)
+			cb.failures = 0
+		} else {
+			cb.setState(circuitOpen)
+			cb.openedAt = time.Now()
+		}
+
+		return
+	}
+
+	if success {
+		cb.failures = 0
+		return
+	}
+
+	now := time.Now()
+	if cb.failures == 0 || now.Sub(cb.firstFailure) > cb.window {
+		cb.failures = 0
+		cb.firstFailure = now
+	}
+
+	cb.failures++
+	if cb.failures >= cb.threshold {
+		cb.setState(circuitOpen)
+		cb.openedAt = now
+	}
+}
+
+// abort releases a request allowed by the circuit breaker without recording its outcome.
+func (cb *circuitBreaker) abort(generation uint64) {
+	cb.mx.Lock()
+	defer cb.mx.Unlock()
+
+	if generation == cb.generation && cb.state == circuitHalfOpen {
+		cb.probing = false
+	}
+}
@@ -0,0 +1,172 @@
+package goproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newStatusServer returns a proxy answering with the current status, and counting its requests.
+// The requests to the modules containing "slow" block until release is closed, then succeed.
+func newStatusServer(t *testing.T, status *atomic.Int64, nbRequests *atomic.Int64, release chan struct{}) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nbRequests.Add(1)
+
+		if strings.Contains(r.URL.Path, "slow") {
+			<-release
+			_, _ = w.Write([]byte(`{"Version":"v1.0.0"}`))
+			return
+		}
+
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(`{"Version":"v1.0.0"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func getInfo(client Client, modulePath string) error {
+	_, err := client.GetModuleInfo(context.Background(), modulePath, "v1.0.0", false)
+	return err
+}
+
+func TestCircuitBreakerThreshold(t *testing.T) {
+	var status, nbRequests atomic.Int64
+	status.Store(http.StatusInternalServerError)
+	server := newStatusServer(t, &status, &nbRequests, nil)
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL), WithCircuitBreaker(3, time.Minute, time.Hour))
+
+	for i := range 3 {
+		if err := getInfo(client, "example.com/m"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected request %d to fail with the server error, got %v", i, err)
+		}
+	}
+
+	if err := getInfo(client, "example.com/m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	if nbRequests.Load() != 3 {
+		t.Errorf("expected 3 requests, got %d", nbRequests.Load())
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	var status, nbRequests atomic.Int64
+	server := newStatusServer(t, &status, &nbRequests, nil)
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL), WithCircuitBreaker(2, time.Minute, time.Hour))
+
+	for _, code := range []int{http.StatusInternalServerError, http.StatusOK, http.StatusInternalServerError, http.StatusOK} {
+		status.Store(int64(code))
+		if err := getInfo(client, "example.com/m"); errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("expected the circuit to stay closed, got %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerCooldown(t *testing.T) {
+	var status, nbRequests atomic.Int64
+	status.Store(http.StatusInternalServerError)
+	server := newStatusServer(t, &status, &nbRequests, nil)
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL), WithCircuitBreaker(1, time.Minute, 50*time.Millisecond))
+
+	_ = getInfo(client, "example.com/m")
+	if err := getInfo(client, "example.com/m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen during the cooldown, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// The probe fails, so the circuit opens again for another cooldown
+	if err := getInfo(client, "example.com/m"); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected the probe to fail with the server error, got %v", err)
+	}
+
+	if err := getInfo(client, "example.com/m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after a failed probe, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	status.Store(http.StatusOK)
+
+	// The probe succeeds, so the circuit closes
+	for range 2 {
+		if err := getInfo(client, "example.com/m"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenSingleProbe(t *testing.T) {
+	var status, nbRequests atomic.Int64
+	status.Store(http.StatusInternalServerError)
+	release := make(chan struct{})
+	server := newStatusServer(t, &status, &nbRequests, release)
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL), WithCircuitBreaker(1, time.Minute, 50*time.Millisecond))
+
+	_ = getInfo(client, "example.com/m")
+	time.Sleep(60 * time.Millisecond)
+
+	chProbe := make(chan error)
+	go func() {
+		chProbe <- getInfo(client, "example.com/slow")
+	}()
+
+	for nbRequests.Load() < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Only the probe goes through while the circuit is half-open
+	if err := getInfo(client, "example.com/m"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen while probing, got %v", err)
+	}
+
+	close(release)
+	if err := <-chProbe; err != nil {
+		t.Fatalf("unexpected probe error: %v", err)
+	}
+
+	status.Store(http.StatusOK)
+	if err := getInfo(client, "example.com/m"); err != nil {
+		t.Errorf("expected the circuit to be closed after the probe, got %v", err)
+	}
+}
+
+func TestCircuitBreakerIgnoresStaleRequests(t *testing.T) {
+	cb := &circuitBreaker{threshold: 1, window: time.Minute}
+
+	// A request is allowed while the circuit is closed, then another one fails and opens it
+	stale, err := cb.allow()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	generation, err := cb.allow()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cb.record(generation, false)
+
+	// Without cooldown, the next request is the half-open probe
+	if _, err := cb.allow(); err != nil {
+		t.Fatalf("expected the probe to be allowed, got %v", err)
+	}
+
+	// The late success of the first request must not close the circuit while the probe is in flight
+	cb.record(stale, true)
+	if _, err := cb.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen while probing, got %v", err)
+	}
+}
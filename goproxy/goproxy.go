@@ -6,16 +6,20 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"golang.org/x/mod/modfile"
 )
 
 const (
-	proxyURL = "https://proxy.golang.org"
-	indexURL = "https://index.golang.org"
+	defaultProxyURL  = "https://proxy.golang.org"
+	defaultIndexURL  = "https://index.golang.org"
+	defaultIndexPath = "/index"
 )
 
 type ModuleInfo struct {
@@ -35,7 +39,22 @@ type Index struct {
 }
 
 type client struct {
-	httpClient *http.Client
+	httpClient     *http.Client
+	transport      *http.Transport
+	circuitBreaker *circuitBreaker
+	sumDB          *sumDB
+	maxRetries     int
+	retryPolicy    RetryPolicy
+
+	// proxyURL is the proxy the modules are fetched from, or empty if fetchErr is set.
+	proxyURL string
+	fetchErr error
+
+	// indexURL and indexPath locate the index feed, and indexIncludeAll sends the include=all parameter,
+	// which the index mirrors don't necessarily support.
+	indexURL        string
+	indexPath       string
+	indexIncludeAll bool
 }
 
 type Client interface {
@@ -43,25 +62,83 @@ type Client interface {
 	GetModuleLatestInfo(ctx context.Context, modulePath string, cachedOnly bool) (ModuleInfo, error)
 	GetModuleInfo(ctx context.Context, modulePath, version string, cachedOnly bool) (ModuleInfo, error)
 	GetModuleModFile(ctx context.Context, modulePath, version string, cachedOnly bool) (*modfile.File, error)
+	GetModuleModFileRaw(ctx context.Context, modulePath, version string, cachedOnly bool) ([]byte, error)
+	ListModuleVersions(ctx context.Context, modulePath string, cachedOnly bool) ([]string, error)
 }
 
-func NewGoProxyClient() Client {
-	return &client{
+type Option func(*client)
+
+// WithHTTPTimeout sets the timeout of each request to the proxy and the index, 3 seconds by default.
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(c *client) {
+		c.httpClient.Timeout = timeout
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections kept across all hosts, 256 by default.
+func WithMaxIdleConns(maxIdleConns int) Option {
+	return func(c *client) {
+		c.transport.MaxIdleConns = maxIdleConns
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections kept per host, which should be at least the number of parallel requests, 128 by default.
+func WithMaxIdleConnsPerHost(maxIdleConnsPerHost int) Option {
+	return func(c *client) {
+		c.transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept before being closed, 90 seconds by default.
+func WithIdleConnTimeout(idleConnTimeout time.Duration) Option {
+	return func(c *client) {
+		c.transport.IdleConnTimeout = idleConnTimeout
+	}
+}
+
+func NewGoProxyClient(options ...Option) Client {
+	// All the requests go to the same few hosts, so the idle connections are kept to avoid reconnecting under high parallelism
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 256
+	transport.MaxIdleConnsPerHost = 128
+	transport.IdleConnTimeout = 90 * time.Second
+
+	c := &client{
 		httpClient: &http.Client{
-			Timeout: 3 * time.Second,
+			Timeout:   3 * time.Second,
+			Transport: transport,
 		},
+		transport:       transport,
+		proxyURL:        defaultProxyURL,
+		indexURL:        defaultIndexURL,
+		indexPath:       defaultIndexPath,
+		indexIncludeAll: true,
+		retryPolicy:     DefaultRetryPolicy,
 	}
+
+	for _, option := range options {
+		option(c)
+	}
+
+	return c
 }
 
 var (
 	ErrModuleNotFound = errors.New("module not found")
 	ErrInvalidModFile = errors.New("invalid mod file")
+	ErrFetchDisabled  = errors.New("module fetch disabled by GOPROXY=off")
+	ErrDirectFetch    = errors.New("direct module fetch from version control is not supported")
+
+	// ErrCachedOnlyUnsupported is returned by the cached-only requests to the proxies other than proxy.golang.org.
+	// It wraps ErrModuleNotFound, so that the callers falling back to a live request on a not found module do it
+	// without sending the same request twice.
+	ErrCachedOnlyUnsupported = fmt.Errorf("cached-only requests are only supported by proxy.golang.org: %w", ErrModuleNotFound)
 )
 
 const ListIndexMaxLimit = 2000
 
 func (c *client) ListIndex(ctx context.Context, since time.Time) ([]Index, error) {
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, indexURL+"/index", nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.indexURL+c.indexPath, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -69,10 +146,12 @@ func (c *client) ListIndex(ctx context.Context, since time.Time) ([]Index, error
 	queryParams := request.URL.Query()
 	queryParams.Add("since", since.Format(time.RFC3339Nano))
 	queryParams.Add("limit", strconv.Itoa(ListIndexMaxLimit))
-	queryParams.Add("include", "all")
+	if c.indexIncludeAll {
+		queryParams.Add("include", "all")
+	}
 	request.URL.RawQuery = queryParams.Encode()
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -102,17 +181,17 @@ func (c *client) ListIndex(ctx context.Context, since time.Time) ([]Index, error
 }
 
 func (c *client) GetModuleLatestInfo(ctx context.Context, modulePath string, cachedOnly bool) (ModuleInfo, error) {
-	cachedOnlyPath := ""
-	if cachedOnly {
-		cachedOnlyPath = "/cached-only"
+	moduleURL, err := c.moduleURL(modulePath, cachedOnly)
+	if err != nil {
+		return ModuleInfo{}, err
 	}
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyURL+cachedOnlyPath+"/"+modulePath+"/@latest", nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleURL+"/@latest", nil)
 	if err != nil {
 		return ModuleInfo{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(request)
 	if err != nil {
 		return ModuleInfo{}, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -135,23 +214,27 @@ func (c *client) GetModuleLatestInfo(ctx context.Context, modulePath string, cac
 }
 
 func (c *client) GetModuleInfo(ctx context.Context, modulePath, version string, cachedOnly bool) (ModuleInfo, error) {
-	cachedOnlyPath := ""
-	if cachedOnly {
-		cachedOnlyPath = "/cached-only"
+	moduleURL, err := c.moduleURL(modulePath, cachedOnly)
+	if err != nil {
+		return ModuleInfo{}, err
 	}
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyURL+cachedOnlyPath+"/"+modulePath+"/@v/"+version+".info", nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleURL+"/@v/"+version+".info", nil)
 	if err != nil {
 		return ModuleInfo{}, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(request)
 	if err != nil {
 		return ModuleInfo{}, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer response.Body.Close()
 
 	if response.StatusCode != http.StatusOK {
+		if response.StatusCode == http.StatusNotFound {
+			return ModuleInfo{}, ErrModuleNotFound
+		}
+
 		return ModuleInfo{}, fmt.Errorf("unexpected status code: %d", response.StatusCode)
 	}
 
@@ -164,17 +247,38 @@ func (c *client) GetModuleInfo(ctx context.Context, modulePath, version string,
 }
 
 func (c *client) GetModuleModFile(ctx context.Context, modulePath, version string, cachedOnly bool) (*modfile.File, error) {
-	cachedOnlyPath := ""
-	if cachedOnly {
-		cachedOnlyPath = "/cached-only"
+	data, err := c.GetModuleModFileRaw(ctx, modulePath, version, cachedOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.sumDB != nil {
+		if err := c.verifyModFile(ctx, modulePath, version, data); err != nil {
+			return nil, fmt.Errorf("failed to verify go.mod file: %w", err)
+		}
+	}
+
+	file, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response as modfile: %w", ErrInvalidModFile)
+	}
+
+	return file, nil
+}
+
+// GetModuleModFileRaw gets the go.mod file of a module version as served by the proxy, without parsing nor verifying it.
+func (c *client) GetModuleModFileRaw(ctx context.Context, modulePath, version string, cachedOnly bool) ([]byte, error) {
+	moduleURL, err := c.moduleURL(modulePath, cachedOnly)
+	if err != nil {
+		return nil, err
 	}
 
-	request, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyURL+cachedOnlyPath+"/"+modulePath+"/@v/"+version+".mod", nil)
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleURL+"/@v/"+version+".mod", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	response, err := c.httpClient.Do(request)
+	response, err := c.do(request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -193,10 +297,213 @@ func (c *client) GetModuleModFile(ctx context.Context, modulePath, version strin
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	file, err := modfile.Parse("go.mod", data, nil)
+	return data, nil
+}
+
+// ListModuleVersions lists the tagged versions of a module, pseudo-versions excluded.
+func (c *client) ListModuleVersions(ctx context.Context, modulePath string, cachedOnly bool) ([]string, error) {
+	moduleURL, err := c.moduleURL(modulePath, cachedOnly)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response as modfile: %w", ErrInvalidModFile)
+		return nil, err
 	}
 
-	return file, nil
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, moduleURL+"/@v/list", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	response, err := c.do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		if response.StatusCode == http.StatusNotFound {
+			return nil, ErrModuleNotFound
+		}
+
+		return nil, fmt.Errorf("unexpected status code: %d", response.StatusCode)
+	}
+
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	return strings.Fields(string(data)), nil
+}
+
+// WithGOPROXY configures the proxy from a GOPROXY value, eg. "https://proxy.golang.org,direct".
+// Only the first proxy URL is used, the fallback to the next entries isn't supported. The "direct" entries are skipped,
+// as fetching from version control isn't supported, and an "off" entry before any URL disables the module fetches.
+// An empty value keeps the default proxy.
+func WithGOPROXY(value string) Option {
+	return func(c *client) {
+		if value == "" {
+			return
+		}
+
+		c.proxyURL = ""
+		c.fetchErr = ErrDirectFetch
+		for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '|' }) {
+			switch entry = strings.TrimSpace(entry); entry {
+			case "direct":
+				continue
+
+			case "off":
+				c.fetchErr = ErrFetchDisabled
+
+			default:
+				c.proxyURL = strings.TrimSuffix(entry, "/")
+				c.fetchErr = nil
+			}
+
+			return
+		}
+	}
+}
+
+// WithIndexURL configures the base URL of the index, for the mirrors of index.golang.org. An empty value keeps the default index.
+func WithIndexURL(indexURL string) Option {
+	return func(c *client) {
+		if indexURL == "" {
+			return
+		}
+
+		c.indexURL = strings.TrimSuffix(indexURL, "/")
+	}
+}
+
+// WithIndexPath configures the path of the index feed relative to the index URL, "/index" by default.
+// An empty value keeps the default path.
+func WithIndexPath(indexPath string) Option {
+	return func(c *client) {
+		if indexPath == "" {
+			return
+		}
+
+		c.indexPath = "/" + strings.TrimPrefix(indexPath, "/")
+	}
+}
+
+// WithIndexIncludeAll configures whether the include=all parameter is sent to the index, which is enabled by default.
+// It must be disabled for the indexes rejecting unknown parameters.
+func WithIndexIncludeAll(includeAll bool) Option {
+	return func(c *client) {
+		c.indexIncludeAll = includeAll
+	}
+}
+
+// moduleURL returns the base URL of the requests of a module.
+// The cached-only mode is specific to proxy.golang.org, so it fails with ErrCachedOnlyUnsupported for the other proxies.
+func (c *client) moduleURL(modulePath string, cachedOnly bool) (string, error) {
+	if c.fetchErr != nil {
+		return "", c.fetchErr
+	}
+
+	if cachedOnly {
+		if c.proxyURL != defaultProxyURL {
+			return "", ErrCachedOnlyUnsupported
+		}
+
+		return c.proxyURL + "/cached-only/" + modulePath, nil
+	}
+
+	return c.proxyURL + "/" + modulePath, nil
+}
+
+// do executes a request, and retries it with an exponential backoff according to the retry policy.
+// The requests are only GET requests without body, so they can be sent again as is.
+func (c *client) do(request *http.Request) (*http.Response, error) {
+	ctx := request.Context()
+
+	retryBackoff := newRetryBackOff()
+	for attempt := 0; ; attempt++ {
+		response, err := c.doOnce(request)
+		if attempt >= c.maxRetries || ctx.Err() != nil {
+			return response, err
+		}
+
+		// Whatever the retry policy, retrying can't get through an open circuit breaker
+		if errors.Is(err, ErrCircuitOpen) {
+			return response, err
+		}
+
+		statusCode := 0
+		if err == nil {
+			statusCode = response.StatusCode
+		}
+
+		if !c.retryPolicy(statusCode, err) {
+			return response, err
+		}
+
+		wait := retryBackoff.NextBackOff()
+		if wait == backoff.Stop {
+			return response, err
+		}
+
+		if response != nil {
+			// The body is drained so that the connection is reused by the next attempt
+			_, _ = io.Copy(io.Discard, response.Body)
+			_ = response.Body.Close()
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = fmt.Errorf("unexpected status code: %d", statusCode)
+			}
+
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *client) doOnce(request *http.Request) (*http.Response, error) {
+	var generation uint64
+	if c.circuitBreaker != nil {
+		var err error
+		generation, err = c.circuitBreaker.allow()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil && request.Context().Err() != nil {
+		// A request canceled by the caller says nothing about the health of the proxy
+		if c.circuitBreaker != nil {
+			c.circuitBreaker.abort(generation)
+		}
+
+		// The context error is wrapped explicitly, so that errors.Is(err, context.DeadlineExceeded) or context.Canceled
+		// holds whatever the transport returned
+		return nil, fmt.Errorf("%w: %w", request.Context().Err(), err)
+	}
+
+	if c.circuitBreaker != nil {
+		if err != nil {
+			c.circuitBreaker.record(generation, false)
+		} else {
+			c.circuitBreaker.record(generation, response.StatusCode < http.StatusInternalServerError && response.StatusCode != http.StatusTooManyRequests)
+		}
+	}
+
+	return response, err
+}
+
+// IsTimeout reports whether err is caused by a timeout, either of the HTTP client or of the context of the request.
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
 }
@@ -0,0 +1,85 @@
+package goproxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientNotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL))
+	ctx := context.Background()
+
+	for name, fetch := range map[string]func() error{
+		"GetModuleLatestInfo": func() error {
+			_, err := client.GetModuleLatestInfo(ctx, "example.com/m", false)
+			return err
+		},
+		"GetModuleInfo": func() error {
+			_, err := client.GetModuleInfo(ctx, "example.com/m", "v1.0.0", false)
+			return err
+		},
+		"GetModuleModFileRaw": func() error {
+			_, err := client.GetModuleModFileRaw(ctx, "example.com/m", "v1.0.0", false)
+			return err
+		},
+		"ListModuleVersions": func() error {
+			_, err := client.ListModuleVersions(ctx, "example.com/m", false)
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := fetch(); !errors.Is(err, ErrModuleNotFound) {
+				t.Errorf("expected ErrModuleNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGetModuleInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/m/@v/v1.0.0.info" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"Version":"v1.0.0","Time":"2024-01-02T03:04:05Z","Origin":{"VCS":"git","URL":"https://example.com/m","Hash":"abc"}}`))
+	}))
+	defer server.Close()
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL))
+
+	info, err := client.GetModuleInfo(context.Background(), "example.com/m", "v1.0.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if info.Version != "v1.0.0" || info.Origin.VCS != "git" || info.Origin.URL != "https://example.com/m" || info.Origin.Hash != "abc" {
+		t.Errorf("unexpected module info: %+v", info)
+	}
+}
+
+func TestCachedOnlyUnsupported(t *testing.T) {
+	var nbRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nbRequests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	client := NewGoProxyClient(WithGOPROXY(server.URL))
+
+	_, err := client.GetModuleInfo(context.Background(), "example.com/m", "v1.0.0", true)
+	if !errors.Is(err, ErrCachedOnlyUnsupported) || !errors.Is(err, ErrModuleNotFound) {
+		t.Errorf("expected ErrCachedOnlyUnsupported wrapping ErrModuleNotFound, got %v", err)
+	}
+
+	if nbRequests != 0 {
+		t.Errorf("expected no request, got %d", nbRequests)
+	}
+}
@@ -0,0 +1,57 @@
+package goproxy
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// IterIndex pages through the index from since to until and calls fn for each entry, in order.
+// The entries sharing the timestamp of a page boundary are only passed once, even though the index returns them again on the next page.
+// A zero until means no upper bound.
+func IterIndex(ctx context.Context, client Client, since, until time.Time, fn func(Index) error) error {
+	seenAtCursor := map[Index]struct{}{}
+
+	for {
+		index, err := client.ListIndex(ctx, since)
+		if err != nil {
+			return fmt.Errorf("failed to list index: %w", err)
+		}
+
+		nbNew := 0
+		for _, i := range index {
+			if !until.IsZero() && i.Timestamp.After(until) {
+				return nil
+			}
+
+			if _, ok := seenAtCursor[i]; ok {
+				continue
+			}
+
+			if !i.Timestamp.Equal(since) {
+				since = i.Timestamp
+				clear(seenAtCursor)
+			}
+			seenAtCursor[i] = struct{}{}
+			nbNew++
+
+			if err := fn(i); err != nil {
+				return err
+			}
+		}
+
+		if len(index) < ListIndexMaxLimit {
+			return nil
+		}
+
+		if nbNew == 0 {
+			return fmt.Errorf("more than %d index entries at %s, the cursor can't move forward", ListIndexMaxLimit, since.Format(time.RFC3339Nano))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package goproxy
+
+import (
+	"net/http"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+// newRetryBackOff returns the backoff between the retries of a request, it is replaced by the tests to retry immediately.
+var newRetryBackOff = func() backoff.BackOff {
+	return backoff.NewExponentialBackOff()
+}
+
+// RetryPolicy reports whether a request is retried, given the status code of its response, or the error of the request
+// with a zero status code.
+type RetryPolicy func(statusCode int, err error) bool
+
+// DefaultRetryPolicy retries the timeouts, the server errors and the rate limited requests, which are transient
+// on the public proxy, but not the other client errors such as a not found module.
+func DefaultRetryPolicy(statusCode int, err error) bool {
+	if err != nil {
+		return IsTimeout(err)
+	}
+
+	return statusCode >= http.StatusInternalServerError || statusCode == http.StatusTooManyRequests
+}
+
+// WithRetries retries the requests to the proxy and the index up to maxRetries times with an exponential backoff,
+// when the retry policy allows it. Each attempt counts for the circuit breaker, and the retries stop once it is open,
+// whatever the retry policy.
+func WithRetries(maxRetries int) Option {
+	return func(c *client) {
+		c.maxRetries = max(maxRetries, 0)
+	}
+}
+
+// WithRetryPolicy replaces DefaultRetryPolicy, for the proxies whose transient errors differ from the public proxy,
+// eg. a proxy returning 403 under load. It only applies with WithRetries.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *client) {
+		if policy == nil {
+			policy = DefaultRetryPolicy
+		}
+
+		c.retryPolicy = policy
+	}
+}
@@ -0,0 +1,77 @@
+package goproxy
+
+import (
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+)
+
+func TestRetries(t *testing.T) {
+	newRetryBackOff = func() backoff.BackOff { return &backoff.ZeroBackOff{} }
+	t.Cleanup(func() {
+		newRetryBackOff = func() backoff.BackOff { return backoff.NewExponentialBackOff() }
+	})
+
+	alwaysRetry := func(int, error) bool { return true }
+	retryForbidden := func(statusCode int, err error) bool {
+		return statusCode == http.StatusForbidden || DefaultRetryPolicy(statusCode, err)
+	}
+
+	for _, tc := range []struct {
+		name               string
+		status             int
+		options            []Option
+		expectedNbRequests int64
+		expectedErr        error
+	}{
+		{name: "no retries", status: http.StatusInternalServerError, expectedNbRequests: 1},
+		{name: "server error", status: http.StatusInternalServerError, options: []Option{WithRetries(2)}, expectedNbRequests: 3},
+		{name: "rate limited", status: http.StatusTooManyRequests, options: []Option{WithRetries(2)}, expectedNbRequests: 3},
+		{name: "client error", status: http.StatusForbidden, options: []Option{WithRetries(2)}, expectedNbRequests: 1},
+		{name: "success", status: http.StatusOK, options: []Option{WithRetries(2)}, expectedNbRequests: 1},
+		{
+			name:               "policy override",
+			status:             http.StatusForbidden,
+			options:            []Option{WithRetries(2), WithRetryPolicy(retryForbidden)},
+			expectedNbRequests: 3,
+		},
+		{
+			name:               "nil policy",
+			status:             http.StatusForbidden,
+			options:            []Option{WithRetries(2), WithRetryPolicy(nil)},
+			expectedNbRequests: 1,
+		},
+		{
+			name:               "open circuit breaker",
+			status:             http.StatusInternalServerError,
+			options:            []Option{WithRetries(5), WithRetryPolicy(alwaysRetry), WithCircuitBreaker(1, time.Minute, time.Hour)},
+			expectedNbRequests: 1,
+			expectedErr:        ErrCircuitOpen,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var status, nbRequests atomic.Int64
+			status.Store(int64(tc.status))
+			server := newStatusServer(t, &status, &nbRequests, nil)
+
+			client := NewGoProxyClient(append([]Option{WithGOPROXY(server.URL)}, tc.options...)...)
+
+			err := getInfo(client, "example.com/m")
+			if tc.status == http.StatusOK && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if tc.expectedErr != nil && !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected %v, got %v", tc.expectedErr, err)
+			}
+
+			if nbRequests.Load() != tc.expectedNbRequests {
+				t.Errorf("expected %d requests, got %d", tc.expectedNbRequests, nbRequests.Load())
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package goproxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+const (
+	sumDBURL = "https://sum.golang.org"
+	sumDBKey = "sum.golang.org+033de0ae+Ac4zctda0e5eza+HJyk9SxEdh+s3Ux18htTTAD8OuAn8"
+)
+
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+type sumDB struct {
+	url       string
+	verifiers note.Verifiers
+
+	// noSumCheck are the GONOSUMDB (or GOPRIVATE) patterns of the modules not verified.
+	noSumCheck string
+}
+
+// WithSumDB verifies the go.mod files fetched by GetModuleModFile against the checksum database sum.golang.org,
+// and fails with ErrChecksumMismatch if they differ, to detect a corrupt or malicious proxy.
+// Like the go command, the verification is disabled by GOSUMDB=off (or GONOSUMCHECK=1), and skipped for the modules matching GONOSUMDB or GOPRIVATE.
+func WithSumDB(enabled bool) Option {
+	return func(c *client) {
+		if !enabled || os.Getenv("GOSUMDB") == "off" || os.Getenv("GONOSUMCHECK") == "1" {
+			return
+		}
+
+		verifier, err := note.NewVerifier(sumDBKey)
+		if err != nil {
+			// The key is a constant, so this can't happen
+			panic(err)
+		}
+
+		noSumCheck := os.Getenv("GONOSUMDB")
+		if noSumCheck == "" {
+			noSumCheck = os.Getenv("GOPRIVATE")
+		}
+
+		c.sumDB = &sumDB{
+			url:        sumDBURL,
+			verifiers:  note.VerifierList(verifier),
+			noSumCheck: noSumCheck,
+		}
+	}
+}
+
+// verifyModFile compares the hash of a go.mod file to the one stored in the checksum database.
+// The signature of the database tree is verified, but not the inclusion of the record in the tree.
+func (c *client) verifyModFile(ctx context.Context, modulePath, version string, data []byte) error {
+	if module.MatchPrefixPatterns(c.sumDB.noSumCheck, modulePath) {
+		return nil
+	}
+
+	hash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to hash go.mod file: %w", err)
+	}
+
+	escapedPath, err := module.EscapePath(modulePath)
+	if err != nil {
+		return fmt.Errorf("failed to escape module path: %w", err)
+	}
+
+	escapedVersion, err := module.EscapeVersion(version)
+	if err != nil {
+		return fmt.Errorf("failed to escape module version: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, c.sumDB.url+"/lookup/"+escapedPath+"@"+escapedVersion, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// The checksum database is a different host than the proxy, so its requests don't go through the circuit breaker
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code from checksum database: %d", response.StatusCode)
+	}
+
+	record, err := io.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	_, text, treeMsg, err := tlog.ParseRecord(record)
+	if err != nil {
+		return fmt.Errorf("failed to parse checksum database record: %w", err)
+	}
+
+	if _, err := note.Open(treeMsg, c.sumDB.verifiers); err != nil {
+		return fmt.Errorf("failed to verify checksum database signature: %w", err)
+	}
+
+	prefix := modulePath + " " + version + "/go.mod "
+	for _, line := range strings.Split(string(text), "\n") {
+		if expected, ok := strings.CutPrefix(line, prefix); ok {
+			if expected != hash {
+				return fmt.Errorf("%w for %s@%s/go.mod: downloaded %s, checksum database %s", ErrChecksumMismatch, modulePath, version, hash, expected)
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("go.mod checksum of %s@%s not found in checksum database", modulePath, version)
+}
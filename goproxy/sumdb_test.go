@@ -0,0 +1,125 @@
+package goproxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/mod/sumdb/dirhash"
+	"golang.org/x/mod/sumdb/note"
+	"golang.org/x/mod/sumdb/tlog"
+)
+
+const testModFile = "module example.com/m\n\ngo 1.22\n"
+
+func hashModFile(t *testing.T, data string) string {
+	t.Helper()
+
+	hash, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return io.NopCloser(strings.NewReader(data)), nil
+	})
+	if err != nil {
+		t.Fatalf("failed to hash go.mod file: %v", err)
+	}
+
+	return hash
+}
+
+// newSumDBClient returns a client fetching testModFile from a fake proxy, and verifying it against a fake checksum
+// database serving the given go.mod hash. The tree note is tampered with after its signature if tamper is true.
+func newSumDBClient(t *testing.T, hash string, tamper bool) Client {
+	t.Helper()
+
+	signerKey, verifierKey, err := note.GenerateKey(rand.Reader, "sum.example.com")
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	signer, err := note.NewSigner(signerKey)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	verifier, err := note.NewVerifier(verifierKey)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	tree, err := note.Sign(&note.Note{Text: string(tlog.FormatTree(tlog.Tree{N: 1, Hash: tlog.RecordHash([]byte("record"))}))}, signer)
+	if err != nil {
+		t.Fatalf("failed to sign tree: %v", err)
+	}
+
+	if tamper {
+		tree = bytes.Replace(tree, []byte("\n1\n"), []byte("\n2\n"), 1)
+	}
+
+	sumDBServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/lookup/example.com/m@v1.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+
+		fmt.Fprintf(w, "1\nexample.com/m v1.0.0 h1:unused\nexample.com/m v1.0.0/go.mod %s\n\n", hash)
+		_, _ = w.Write(tree)
+	}))
+	t.Cleanup(sumDBServer.Close)
+
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/example.com/m/@v/v1.0.0.mod" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, _ = w.Write([]byte(testModFile))
+	}))
+	t.Cleanup(proxyServer.Close)
+
+	c := NewGoProxyClient(WithGOPROXY(proxyServer.URL)).(*client)
+	c.sumDB = &sumDB{
+		url:       sumDBServer.URL,
+		verifiers: note.VerifierList(verifier),
+	}
+
+	return c
+}
+
+func TestVerifyModFile(t *testing.T) {
+	client := newSumDBClient(t, hashModFile(t, testModFile), false)
+
+	modFile, err := client.GetModuleModFile(context.Background(), "example.com/m", "v1.0.0", false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if modFile.Module.Mod.Path != "example.com/m" {
+		t.Errorf("expected module example.com/m, got %s", modFile.Module.Mod.Path)
+	}
+}
+
+func TestVerifyModFileMismatch(t *testing.T) {
+	client := newSumDBClient(t, hashModFile(t, "module example.com/other\n"), false)
+
+	_, err := client.GetModuleModFile(context.Background(), "example.com/m", "v1.0.0", false)
+	if !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestVerifyModFileBadSignature(t *testing.T) {
+	client := newSumDBClient(t, hashModFile(t, testModFile), true)
+
+	_, err := client.GetModuleModFile(context.Background(), "example.com/m", "v1.0.0", false)
+
+	var signatureErr *note.InvalidSignatureError
+	if !errors.As(err, &signatureErr) {
+		t.Errorf("expected an invalid signature error, got %v", err)
+	}
+}
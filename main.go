@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"runtime"
+	"runtime/debug"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/Thiht/go-command"
@@ -16,23 +20,62 @@ import (
 )
 
 func main() {
-	ctx := context.Background()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn})))
 
-	driver, err := setupNeo4j(ctx)
-	if err != nil {
-		slog.Error("failed to setup neo4j", slog.Any("error", err))
-		os.Exit(1)
-	}
-	defer driver.Close(ctx)
+	var (
+		driver        neo4j.DriverWithContext
+		goProxyClient goproxy.Client
+	)
+
+	// withNeo4jIf sets up the neo4j driver only when enabled returns true, for commands using it optionally
+	withNeo4jIf := func(enabled func(*flag.FlagSet) bool) command.Middleware {
+		return func(next command.Handler) command.Handler {
+			return func(ctx context.Context, flagSet *flag.FlagSet, args []string) int {
+				if !enabled(flagSet) {
+					return next(ctx, flagSet, args)
+				}
 
-	goProxyClient := goproxy.NewGoProxyClient()
+				var err error
+				driver, err = setupNeo4j(ctx)
+				if err != nil {
+					slog.Error("failed to setup neo4j", slog.Any("error", err))
+					return 1
+				}
+				defer driver.Close(ctx)
+
+				return next(ctx, flagSet, args)
+			}
+		}
+	}
+	withNeo4j := withNeo4jIf(func(*flag.FlagSet) bool { return true })
 
 	root := command.Root().Flags(func(flagSet *flag.FlagSet) {
 		flagSet.String("log-level", "warn", "Log level (debug, info, warn, error)")
+		flagSet.Bool("version", false, "Print the version and exit")
+		flagSet.Duration("http-timeout", 3*time.Second, "Timeout of the requests to the Go module proxy and index")
+		flagSet.Int("http-max-idle-conns-per-host", 128, "Maximum number of idle connections kept per host, should be at least the number of parallel workers")
+		flagSet.Int("http-retries", 0, "Number of retries of the requests to the Go module proxy and index failing with a timeout, a 5xx or a 429")
+		flagSet.Int("circuit-breaker-threshold", 0, "Number of consecutive failed requests opening the circuit breaker of the goproxy client (0 to disable)")
+		flagSet.Duration("circuit-breaker-window", time.Minute, "Window in which the consecutive failed requests are counted")
+		flagSet.Duration("circuit-breaker-cooldown", 30*time.Second, "Duration during which requests fail fast once the circuit breaker is open")
+		flagSet.String("goproxy", "https://proxy.golang.org", "GOPROXY value used to fetch the modules, only its first proxy URL is used (off disables the fetches)")
+		flagSet.Bool("goproxy-from-env", false, "Use the GOPROXY environment variable instead of -goproxy when it is set")
+		flagSet.String("index-url", "https://index.golang.org", "Base URL of the Go module index, to use a mirror of index.golang.org")
+		flagSet.String("index-path", "/index", "Path of the index feed relative to -index-url")
+		flagSet.Bool("index-include-all", true, "Send the include=all parameter to the index, disable it for the indexes not supporting it")
+		flagSet.Bool("verify-sumdb", false, "Verify the go.mod files fetched from the Go module proxy against the checksum database (disabled by GOSUMDB=off, skipped for GONOSUMDB)")
+		flagSet.String("node-label", "Module", "Label of the module nodes in Neo4j, to store several graphs in the same database")
+		flagSet.String("rel-type", "DEPENDS_ON", "Type of the dependency relationships in Neo4j, a custom type X also renames the tool and reverse relationships to TOOL_X, X_INVERSE and TOOL_X_INVERSE")
 	}).Middlewares(func(next command.Handler) command.Handler {
 		return func(ctx context.Context, flagSet *flag.FlagSet, args []string) int {
+			if command.Lookup[bool](flagSet, "version") {
+				cmd.PrintVersion(os.Stdout, buildInfo())
+				return 0
+			}
+
 			var level slog.Level
 			if err := level.UnmarshalText([]byte(command.Lookup[string](flagSet, "log-level"))); err != nil {
 				slog.Error("invalid log level, fallback to warn", slog.Any("error", err))
@@ -41,25 +84,283 @@ func main() {
 
 			slog.SetLogLoggerLevel(level)
 
+			if err := cmd.SetGraphSchema(command.Lookup[string](flagSet, "node-label"), command.Lookup[string](flagSet, "rel-type")); err != nil {
+				slog.Error("invalid graph schema", slog.Any("error", err))
+				return 1
+			}
+
+			return next(ctx, flagSet, args)
+		}
+	}, func(next command.Handler) command.Handler {
+		return func(ctx context.Context, flagSet *flag.FlagSet, args []string) int {
+			goProxy := command.Lookup[string](flagSet, "goproxy")
+			if envGoProxy := os.Getenv("GOPROXY"); command.Lookup[bool](flagSet, "goproxy-from-env") && envGoProxy != "" {
+				goProxy = envGoProxy
+			}
+			slog.Info("using goproxy", slog.String("goproxy", goProxy))
+
+			goProxyClient = goproxy.NewGoProxyClient(
+				goproxy.WithHTTPTimeout(command.Lookup[time.Duration](flagSet, "http-timeout")),
+				goproxy.WithRetries(command.Lookup[int](flagSet, "http-retries")),
+				goproxy.WithMaxIdleConnsPerHost(command.Lookup[int](flagSet, "http-max-idle-conns-per-host")),
+				goproxy.WithGOPROXY(goProxy),
+				goproxy.WithSumDB(command.Lookup[bool](flagSet, "verify-sumdb")),
+				goproxy.WithIndexURL(command.Lookup[string](flagSet, "index-url")),
+				goproxy.WithIndexPath(command.Lookup[string](flagSet, "index-path")),
+				goproxy.WithIndexIncludeAll(command.Lookup[bool](flagSet, "index-include-all")),
+				goproxy.WithCircuitBreaker(
+					command.Lookup[int](flagSet, "circuit-breaker-threshold"),
+					command.Lookup[time.Duration](flagSet, "circuit-breaker-window"),
+					command.Lookup[time.Duration](flagSet, "circuit-breaker-cooldown"),
+				),
+			)
+
 			return next(ctx, flagSet, args)
 		}
 	})
+	root.Action(func(_ context.Context, flagSet *flag.FlagSet, args []string) int {
+		if len(args) > 0 {
+			fmt.Fprintf(os.Stderr, "command provided but not defined: %s\n", args[0])
+			flagSet.Usage()
+			return 2
+		}
+
+		flagSet.Usage()
+		return 0
+	})
+	root.SubCommand("version").Action(cmd.VersionHandler(buildInfo()))
 	root.SubCommand("repositories-to-modules").Action(cmd.RepositoriesToModulesHandler()).Flags(func(flagSet *flag.FlagSet) {
 		flagSet.String("input-file", "./data/seed.txt", "File containing a list of Go repositories to convert to Go module paths")
 		flagSet.String("output-file", "./data/seed-modules.txt", "Output file containing the list of Go module paths")
+		flagSet.Bool("gzip", false, "Compress the output file with gzip (implied by a .gz output file)")
+		flagSet.Bool("fail-on-error", false, "Exit with an error without writing the output file if any repository failed")
+		flagSet.Float64("min-success-ratio", 0, "Exit with an error without writing the output file if the ratio of successful repositories is below this value (0 to 1)")
+		flagSet.String("module-subdir", "", "Subdirectory of the repositories in which to look for go.mod files")
+		flagSet.Var(&stringsFlag{}, "exclude-dir", "Name of the directories to skip when looking for go.mod files, eg. vendor or testdata (can be repeated)")
 	})
-	root.SubCommand("list-goproxy-modules").Action(cmd.ListGoProxyModulesHandler(goProxyClient)).Flags(func(flagSet *flag.FlagSet) {
+	root.SubCommand("list-goproxy-modules").Middlewares(withNeo4jIf(func(flagSet *flag.FlagSet) bool {
+		return command.Lookup[bool](flagSet, "since-from-graph")
+	})).Action(lazy(func() command.Handler {
+		return cmd.ListGoProxyModulesHandler(driver, goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
 		flagSet.String("since", "2019-04-10T19:08:52.997264Z", "List modules since this date")
+		flagSet.Bool("since-from-graph", false, "List modules since the most recent version time stored in Neo4j, or since -since if the graph is empty (the versions tagged earlier but indexed later are missed)")
 		flagSet.String("until", time.Now().Format(time.RFC3339Nano), "List modules until this date")
 		flagSet.String("output-file", "./data/go-proxy-modules.txt", "Output file containing the list of Go module paths")
+		flagSet.Bool("gzip", false, "Compress the output file with gzip (implied by a .gz output file)")
+		flagSet.Bool("append-latest", false, "Append the latest version of each module to its line, at the cost of a request per module")
+		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel requests to get the latest versions with -append-latest")
+		flagSet.Var(&stringsFlag{}, "only-hosts", "Only list the modules under this host or path prefix, eg. k8s.io or github.com/hashicorp (can be repeated)")
+	})
+	root.SubCommand("stream-index").Action(lazy(func() command.Handler {
+		return cmd.StreamIndexHandler(goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("since", "2019-04-10T19:08:52.997264Z", "Stream the index since this date")
+		flagSet.String("until", time.Now().Format(time.RFC3339Nano), "Stream the index until this date")
+		flagSet.String("output-file", "./data/go-proxy-index.csv", "Output file containing the index entries")
+		flagSet.Bool("gzip", false, "Compress the output file with gzip (implied by a .gz output file)")
+		flagSet.String("format", "csv", "Output format (csv, jsonl)")
+	})
+	root.SubCommand("process-modules").Middlewares(withNeo4jIf(func(flagSet *flag.FlagSet) bool {
+		if command.Lookup[bool](flagSet, "validate-only") {
+			return false
+		}
+
+		return !command.Lookup[bool](flagSet, "stdout-edges") || command.Lookup[bool](flagSet, "resume-from-graph")
+	})).Action(lazy(func() command.Handler {
+		return cmd.ProcessModulesHandler(driver, goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel workers")
+		flagSet.String("seed-file", "", "File containing the modules to process, one \"<path> [version]\" per line (can be gzipped)")
+		flagSet.Bool("validate-only", false, "Check the module paths and versions of the seed file and exit, without any request to the proxy or Neo4j")
+		flagSet.String("org-rules-file", "", "File containing \"<prefix> <org>\" rules used to extract the org of a module, replacing the default rules")
+		flagSet.Bool("stdout-edges", false, "Write the dependency edges to stdout as JSON lines instead of Neo4j")
+		flagSet.Bool("nodes-only", false, "Only create the module nodes, as the first pass of a two-pass load (see the process-two-pass task)")
+		flagSet.Bool("edges-only", false, "Only create the relationships between existing module nodes, as the second pass of a two-pass load")
+		flagSet.Bool("resume-from-graph", false, "Skip the seed modules already processed in the graph, and queue their dependencies from the graph instead")
+		flagSet.Int("resume-batch-size", 1_000, "Number of seed modules checked per transaction with -resume-from-graph")
+		flagSet.Bool("only-latest", false, "Process the latest version of the seed modules instead of the seeded versions")
+		flagSet.Duration("tx-timeout", 3*time.Second, "Timeout of the transactions creating the dependencies of a module")
+		flagSet.Int("dependencies-batch-size", 500, "Maximum number of dependencies created per transaction")
+		flagSet.String("min-go-version", "", "Skip the modules whose go directive is below this version, eg. 1.18")
+		flagSet.Bool("include-no-go-directive", false, "Process the modules without go directive when -min-go-version is set")
+		flagSet.Float64("sample", 1, "Probability to process each module (0 to 1), to get estimates quickly")
+		flagSet.Int64("sample-seed", 0, "Seed of the sampling, runs with the same seed process the same modules")
+		flagSet.Var(&stringsFlag{}, "exclude-dep-prefix", "Path prefix of the dependencies to exclude from the graph and from processing (can be repeated)")
+	})
+	root.SubCommand("recompute-org-host").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.RecomputeOrgHostHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("org-rules-file", "", "File containing \"<prefix> <org>\" rules used to extract the org of a module, replacing the default rules")
+		flagSet.Int("batch-size", 1_000, "Number of module names updated per transaction")
+	})
+	root.SubCommand("compute-indegree").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.ComputeInDegreeHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("distinct-by", "name", "Count the distinct dependents by name or by name and version (name, name-version)")
+		flagSet.Int("batch-size", 1_000, "Number of module names updated per transaction")
 	})
-	root.SubCommand("process-modules").Action(cmd.ProcessModulesHandler(driver, goProxyClient)).Flags(func(flagSet *flag.FlagSet) {
+	root.SubCommand("import-csv").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.ImportCSVHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("input-file", "", "CSV file with the module, version, time and dependencies columns, see cmd/import-csv.go for the expected format")
+		flagSet.String("org-rules-file", "", "File containing \"<prefix> <org>\" rules used to extract the org of a module, replacing the default rules")
 		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel workers")
-		flagSet.String("seed-file", "", "")
+		flagSet.Duration("tx-timeout", 3*time.Second, "Timeout of the transactions creating the dependencies of a module")
+		flagSet.Int("dependencies-batch-size", 500, "Maximum number of dependencies created per transaction")
+	})
+	root.SubCommand("list-leaf-modules").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.ListLeafModulesHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Int("top", 100, "Maximum number of modules to list (0 for all)")
+		flagSet.Bool("by-deps", false, "Order the modules by number of dependencies instead of by name")
+		flagSet.String("format", "table", "Output format (table, csv)")
+	})
+	root.SubCommand("top-depended").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.TopDependedHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Bool("refresh", false, "Recompute the cached view of the most depended on modules")
+		flagSet.Int("top", 100, "Number of modules stored in the cached view when it is computed")
+		flagSet.String("format", "table", "Output format (table, csv)")
+	})
+	root.SubCommand("version-spread").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.VersionSpreadHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Int("top", 100, "Maximum number of dependencies to list (0 for all)")
+		flagSet.String("format", "table", "Output format (table, csv)")
+	})
+	root.SubCommand("dependencies").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.DependenciesHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("module", "", "Path of the module to list the dependencies of")
+		flagSet.String("module-version", "", "Version of the module to list the dependencies of (all versions if empty)")
+		flagSet.Bool("collapse-versions", false, "Only list the highest version of each dependency path, across all the module versions when -module-version is empty")
+		flagSet.String("format", "table", "Output format (table, csv)")
+	})
+	root.SubCommand("missing-go-mod").Middlewares(withNeo4jIf(func(flagSet *flag.FlagSet) bool {
+		return command.Lookup[string](flagSet, "seed-file") == ""
+	})).Action(lazy(func() command.Handler {
+		return cmd.MissingGoModHandler(driver, goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("seed-file", "", "File containing the modules to check, one per line (can be gzipped), instead of the modules of the graph")
+		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel workers")
+		flagSet.String("output-file", "./data/missing-go-mod.csv", "Output file containing the modules without go.mod")
+		flagSet.Bool("gzip", false, "Compress the output file with gzip (implied by a .gz output file)")
+	})
+	root.SubCommand("version-conflicts").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.VersionConflictsHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("module", "", "Path of the root module")
+		flagSet.String("module-version", "", "Version of the root module (highest version of the graph if empty)")
+		flagSet.Int("max-depth", 10, "Maximum depth of the transitive dependencies (0 for no limit)")
+		flagSet.Int("batch-size", 1_000, "Number of modules queried per transaction")
+		flagSet.String("format", "table", "Output format (table, csv)")
+	})
+	root.SubCommand("tree").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.TreeHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("module", "", "Path of the root module")
+		flagSet.String("module-version", "", "Version of the root module (highest version of the graph if empty)")
+		flagSet.Int("max-depth", 10, "Maximum depth of the tree (0 for no limit)")
+		flagSet.Int("batch-size", 1_000, "Number of modules queried per transaction")
+	})
+	root.SubCommand("retractions").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.RetractionsHandler(driver)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.String("mode", "versions", "List the retracted versions, or the modules depending on them (versions, dependents)")
+		flagSet.String("format", "table", "Output format (table, csv)")
+		flagSet.Int("batch-size", 1_000, "Number of modules queried per transaction")
+	})
+	root.SubCommand("enrich-latest").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.EnrichLatestHandler(driver, goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel workers")
+		flagSet.Int("batch-size", 1_000, "Number of modules updated per transaction")
+		flagSet.String("failed-file", "", "Output file containing the modules that failed to be enriched, one per line")
+		flagSet.Float64("sample", 1, "Probability to process each module (0 to 1), to get estimates quickly")
+		flagSet.Int64("sample-seed", 0, "Seed of the sampling, runs with the same seed process the same modules")
+		flagSet.String("retry-file", "", "File containing the modules to enrich instead of all the modules of the graph, eg. a previous -failed-file")
+		flagSet.Duration("latest-max-age", 0, "Fetch the latest version from the origin when the cached one was released more than this duration ago, whenever it was cached (0 to always use the cached one)")
+	})
+	root.SubCommand("enrich-origin").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.EnrichOriginHandler(driver, goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel workers")
+		flagSet.Int("batch-size", 1_000, "Number of module versions updated per transaction")
+		flagSet.String("failed-file", "", "Output file containing the module versions that failed to be enriched, one \"<path> <version>\" per line")
+		flagSet.String("retry-file", "", "File containing the module versions to enrich instead of the ones of the graph without origin, eg. a previous -failed-file")
+	})
+	root.SubCommand("enrich-major-versions").Middlewares(withNeo4j).Action(lazy(func() command.Handler {
+		return cmd.EnrichMajorVersionsHandler(driver, goProxyClient)
+	})).Flags(func(flagSet *flag.FlagSet) {
+		flagSet.Int("parallel", runtime.NumCPU(), "Number of parallel workers")
+		flagSet.Int("batch-size", 1_000, "Number of base modules updated per transaction")
+		flagSet.Float64("sample", 1, "Probability to process each module (0 to 1), to get estimates quickly")
+		flagSet.Int64("sample-seed", 0, "Seed of the sampling, runs with the same seed process the same modules")
+		flagSet.String("failed-file", "", "Output file containing the base modules that failed to be enriched, one per line")
+		flagSet.String("retry-file", "", "File containing the base modules to enrich instead of all the modules of the graph, eg. a previous -failed-file")
 	})
 	root.Execute(ctx)
 }
 
+// Set with -ldflags "-X main.version=... -X main.commit=... -X main.date=...", see the build task.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo completes the information set at link time with the information embedded by the Go toolchain.
+func buildInfo() cmd.BuildInfo {
+	info := cmd.BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+
+	if debugInfo, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" {
+			info.Version = debugInfo.Main.Version
+		}
+
+		for _, setting := range debugInfo.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	return info
+}
+
+// stringsFlag is a flag that can be repeated to collect multiple values.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+func (s *stringsFlag) Get() any {
+	return []string(*s)
+}
+
+// lazy defers the creation of a handler to its execution, so that it can use dependencies initialized by the middlewares.
+func lazy(newHandler func() command.Handler) command.Handler {
+	return func(ctx context.Context, flagSet *flag.FlagSet, args []string) int {
+		return newHandler()(ctx, flagSet, args)
+	}
+}
+
 func setupNeo4j(ctx context.Context) (neo4j.DriverWithContext, error) {
 	slog.Debug("creating neo4j driver")
 	driver, err := neo4j.NewDriverWithContext("neo4j://localhost", neo4j.NoAuth())
@@ -78,20 +379,14 @@ func setupNeo4j(ctx context.Context) (neo4j.DriverWithContext, error) {
 	session := driver.NewSession(ctx, neo4j.SessionConfig{DatabaseName: ""})
 	defer session.Close(ctx)
 
-	slog.Debug("creating neo4j indexes")
-	if _, err := session.Run(ctx, "CREATE INDEX IF NOT EXISTS FOR (m:Module) ON (m.name);", nil); err != nil {
-		slog.Error("failed to create index on :Module(name)", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to create index on :Module(name): %w", err)
-	}
-
-	if _, err := session.Run(ctx, "CREATE INDEX IF NOT EXISTS FOR (m:Module) ON (m.version);", nil); err != nil {
-		slog.Error("failed to create index on :Module(version)", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to create index on :Module(version): %w", err)
-	}
+	label := cmd.NodeLabel()
 
-	if _, err := session.Run(ctx, "CREATE INDEX IF NOT EXISTS FOR (m:Module) ON (m.org);", nil); err != nil {
-		slog.Error("failed to create index on :Module(org)", slog.Any("error", err))
-		return nil, fmt.Errorf("failed to create index on :Module(org): %w", err)
+	slog.Debug("creating neo4j indexes", slog.String("label", label))
+	for _, property := range []string{"name", "version", "org", "versionTime"} {
+		if _, err := session.Run(ctx, "CREATE INDEX IF NOT EXISTS FOR (m:"+label+") ON (m."+property+");", nil); err != nil {
+			slog.Error("failed to create index", slog.String("label", label), slog.String("property", property), slog.Any("error", err))
+			return nil, fmt.Errorf("failed to create index on :%s(%s): %w", label, property, err)
+		}
 	}
 
 	return driver, nil
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// runMainEnv makes the test binary run main with the arguments of the environment variable instead of the tests,
+// since go-command parses os.Args and exits.
+const runMainEnv = "GO_STATS_TEST_MAIN_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(runMainEnv); ok {
+		os.Args = append([]string{"go-stats"}, strings.Fields(args)...)
+		main()
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+func runMain(t *testing.T, args ...string) string {
+	t.Helper()
+
+	command := exec.Command(os.Args[0])
+	command.Env = append(os.Environ(), runMainEnv+"="+strings.Join(args, " "))
+
+	output, err := command.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go-stats %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+
+	return string(output)
+}
+
+// TestSubCommandsHelp checks that the flags of each subcommand don't collide with the root flags, which go-command copies
+// into every subcommand and which panics on redefinition.
+func TestSubCommandsHelp(t *testing.T) {
+	var subCommands []string
+
+	scanner := bufio.NewScanner(strings.NewReader(runMain(t, "-h")))
+	inSubCommands := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "Subcommands:" {
+			inSubCommands = true
+			continue
+		}
+
+		if inSubCommands && strings.HasPrefix(line, "  ") && !strings.HasPrefix(line, "   ") {
+			subCommands = append(subCommands, strings.TrimSpace(line))
+		}
+	}
+
+	if len(subCommands) == 0 {
+		t.Fatal("expected the usage to list subcommands")
+	}
+
+	for _, subCommand := range subCommands {
+		t.Run(subCommand, func(t *testing.T) {
+			runMain(t, subCommand, "-h")
+		})
+	}
+}
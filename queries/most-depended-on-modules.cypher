@@ -0,0 +1,5 @@
+MATCH (module:Module)
+WHERE module.moduleDependentsCount IS NOT NULL
+RETURN DISTINCT module.name AS module, module.moduleDependentsCount AS dependents
+ORDER BY dependents DESC
+LIMIT 100
@@ -0,0 +1,4 @@
+MATCH (tool)-[:IS_TOOL_DEPENDED_ON_BY]->(dependent)
+RETURN tool.name AS tool, COUNT(dependent) AS dependents
+ORDER BY dependents DESC
+LIMIT 50
@@ -0,0 +1,193 @@
+package semver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidVersion = errors.New("invalid version")
+
+// Version is a parsed module version, as found in the go proxy (eg. v1.2.3, v0.0.0-20240101000000-abcdefabcdef, v2.0.0+incompatible).
+type Version struct {
+	Major, Minor, Patch uint64
+
+	// Prerelease is the dot-separated prerelease identifiers, without the leading "-".
+	Prerelease string
+
+	// Build is the dot-separated build metadata, without the leading "+".
+	Build string
+}
+
+// Parse parses a full semantic version prefixed with "v".
+// Unlike golang.org/x/mod/semver, the shorthands "v1" and "v1.2" are rejected since they are not valid module versions.
+func Parse(v string) (Version, error) {
+	rest, ok := strings.CutPrefix(v, "v")
+	if !ok {
+		return Version{}, fmt.Errorf("%w %q: missing v prefix", ErrInvalidVersion, v)
+	}
+
+	var version Version
+
+	rest, version.Build, ok = strings.Cut(rest, "+")
+	if ok && !validIdentifiers(version.Build, false) {
+		return Version{}, fmt.Errorf("%w %q: invalid build metadata", ErrInvalidVersion, v)
+	}
+
+	rest, version.Prerelease, ok = strings.Cut(rest, "-")
+	if ok && !validIdentifiers(version.Prerelease, true) {
+		return Version{}, fmt.Errorf("%w %q: invalid prerelease", ErrInvalidVersion, v)
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("%w %q: expected major.minor.patch", ErrInvalidVersion, v)
+	}
+
+	for i, dst := range []*uint64{&version.Major, &version.Minor, &version.Patch} {
+		if !isNumeric(parts[i]) || (len(parts[i]) > 1 && parts[i][0] == '0') {
+			return Version{}, fmt.Errorf("%w %q: invalid number %q", ErrInvalidVersion, v, parts[i])
+		}
+
+		n, err := strconv.ParseUint(parts[i], 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w %q: %w", ErrInvalidVersion, v, err)
+		}
+		*dst = n
+	}
+
+	return version, nil
+}
+
+// Valid reports whether v is a valid version, ie. whether Parse succeeds.
+func Valid(v string) bool {
+	_, err := Parse(v)
+	return err == nil
+}
+
+func (v Version) String() string {
+	s := "v" + strconv.FormatUint(v.Major, 10) + "." + strconv.FormatUint(v.Minor, 10) + "." + strconv.FormatUint(v.Patch, 10)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// Incompatible reports whether the version is a v2+ version of a module without go.mod.
+func (v Version) Incompatible() bool {
+	return v.Build == "incompatible"
+}
+
+// Pseudo reports whether the version is a pseudo-version, ie. a prerelease ending with a commit timestamp and hash.
+func (v Version) Pseudo() bool {
+	// vX.0.0-yyyymmddhhmmss-abcdefabcdef, vX.Y.Z-0.yyyymmddhhmmss-abcdefabcdef or vX.Y.Z-pre.0.yyyymmddhhmmss-abcdefabcdef
+	identifiers := strings.Split(v.Prerelease, ".")
+	if len(identifiers) > 1 && identifiers[len(identifiers)-2] != "0" {
+		return false
+	}
+
+	timestamp, revision, ok := strings.Cut(identifiers[len(identifiers)-1], "-")
+	return ok && len(timestamp) == 14 && isNumeric(timestamp) && len(revision) == 12 && strings.Trim(revision, "0123456789abcdef") == ""
+}
+
+// Compare returns -1, 0 or 1 depending on the precedence of a compared to b.
+// The build metadata is ignored, as specified by semver.
+func Compare(a, b Version) int {
+	for _, c := range [][2]uint64{{a.Major, b.Major}, {a.Minor, b.Minor}, {a.Patch, b.Patch}} {
+		if c[0] != c[1] {
+			if c[0] < c[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return comparePrerelease(a.Prerelease, b.Prerelease)
+}
+
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+
+	return 0
+}
+
+func compareIdentifier(a, b string) int {
+	aNumeric, bNumeric := isNumeric(a), isNumeric(b)
+
+	switch {
+	case aNumeric && bNumeric:
+		// Numeric identifiers have no leading zeros, so the longest is the greatest
+		if len(a) != len(b) {
+			if len(a) < len(b) {
+				return -1
+			}
+			return 1
+		}
+	case aNumeric:
+		return -1
+	case bNumeric:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func validIdentifiers(s string, prerelease bool) bool {
+	for _, identifier := range strings.Split(s, ".") {
+		if identifier == "" {
+			return false
+		}
+
+		for _, r := range identifier {
+			if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r == '-') {
+				return false
+			}
+		}
+
+		if prerelease && len(identifier) > 1 && identifier[0] == '0' && isNumeric(identifier) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
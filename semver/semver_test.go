@@ -0,0 +1,188 @@
+package semver
+
+import (
+	"errors"
+	"testing"
+
+	xsemver "golang.org/x/mod/semver"
+)
+
+// Versions harvested from index.golang.org
+var corpus = []struct {
+	version      string
+	expected     Version
+	incompatible bool
+	pseudo       bool
+}{
+	{version: "v0.0.0", expected: Version{}},
+	{version: "v1.9.1", expected: Version{Major: 1, Minor: 9, Patch: 1}},
+	{version: "v0.34.0", expected: Version{Minor: 34}},
+	{version: "v1.10.0-rc.1", expected: Version{Major: 1, Minor: 10, Prerelease: "rc.1"}},
+	{version: "v2.0.0-beta", expected: Version{Major: 2, Prerelease: "beta"}},
+	{version: "v3.4.0-alpha.0.1-fix", expected: Version{Major: 3, Minor: 4, Prerelease: "alpha.0.1-fix"}},
+	{version: "v2.1.0+incompatible", expected: Version{Major: 2, Minor: 1, Build: "incompatible"}, incompatible: true},
+	{version: "v1.0.0+build.5.sha-abc", expected: Version{Major: 1, Build: "build.5.sha-abc"}},
+	{
+		version:  "v0.0.0-20191109021931-daa7c04131f5",
+		expected: Version{Prerelease: "20191109021931-daa7c04131f5"},
+		pseudo:   true,
+	},
+	{
+		version:  "v1.2.4-0.20191109021931-daa7c04131f5",
+		expected: Version{Major: 1, Minor: 2, Patch: 4, Prerelease: "0.20191109021931-daa7c04131f5"},
+		pseudo:   true,
+	},
+	{
+		version:  "v1.2.3-pre.0.20191109021931-daa7c04131f5",
+		expected: Version{Major: 1, Minor: 2, Patch: 3, Prerelease: "pre.0.20191109021931-daa7c04131f5"},
+		pseudo:   true,
+	},
+	{
+		version:      "v4.0.0-20170814212402-1b8b7c3ab1e5+incompatible",
+		expected:     Version{Major: 4, Prerelease: "20170814212402-1b8b7c3ab1e5", Build: "incompatible"},
+		incompatible: true,
+		pseudo:       true,
+	},
+	{version: "v18446744073709551615.0.0", expected: Version{Major: 18446744073709551615}},
+}
+
+func TestParse(t *testing.T) {
+	for _, tc := range corpus {
+		t.Run(tc.version, func(t *testing.T) {
+			actual, err := Parse(tc.version)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if actual != tc.expected {
+				t.Errorf("expected %+v, got %+v", tc.expected, actual)
+			}
+
+			if !Valid(tc.version) {
+				t.Errorf("expected Valid() to be true")
+			}
+
+			if actual.String() != tc.version {
+				t.Errorf("expected String() to be %q, got %q", tc.version, actual.String())
+			}
+
+			if actual.Incompatible() != tc.incompatible {
+				t.Errorf("expected Incompatible() to be %t", tc.incompatible)
+			}
+
+			if actual.Pseudo() != tc.pseudo {
+				t.Errorf("expected Pseudo() to be %t", tc.pseudo)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, version := range []string{
+		"",
+		"v",
+		"1.2.3",
+		"v1",
+		"v1.2",
+		"v1.2.3.4",
+		"v01.2.3",
+		"v1.02.3",
+		"v1.2.3-",
+		"v1.2.3-01",
+		"v1.2.3-rc..1",
+		"v1.2.3+",
+		"v1.2.3+build_1",
+		"v1.2.3 ",
+		"v-1.2.3",
+		"v18446744073709551616.0.0",
+		"latest",
+	} {
+		t.Run(version, func(t *testing.T) {
+			if _, err := Parse(version); !errors.Is(err, ErrInvalidVersion) {
+				t.Errorf("expected ErrInvalidVersion, got %v", err)
+			}
+
+			if Valid(version) {
+				t.Errorf("expected Valid() to be false")
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered by increasing precedence
+	versions := []string{
+		"v0.0.0-20170814212402-1b8b7c3ab1e5",
+		"v0.0.0-20191109021931-daa7c04131f5",
+		"v0.0.0",
+		"v1.0.0-alpha",
+		"v1.0.0-alpha.1",
+		"v1.0.0-alpha.beta",
+		"v1.0.0-beta",
+		"v1.0.0-beta.2",
+		"v1.0.0-beta.11",
+		"v1.0.0-rc.1",
+		"v1.0.0",
+		"v1.2.4-0.20191109021931-daa7c04131f5",
+		"v1.2.4",
+		"v1.10.0",
+		"v2.1.0+incompatible",
+		"v10.0.0",
+	}
+
+	for i := range versions {
+		for j := range versions {
+			a, err := Parse(versions[i])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			b, err := Parse(versions[j])
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			expected := 0
+			switch {
+			case i < j:
+				expected = -1
+			case i > j:
+				expected = 1
+			}
+
+			if actual := Compare(a, b); actual != expected {
+				t.Errorf("expected Compare(%s, %s) to be %d, got %d", versions[i], versions[j], expected, actual)
+			}
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, tc := range corpus {
+		f.Add(tc.version, "v1.0.0")
+	}
+
+	f.Fuzz(func(t *testing.T, a, b string) {
+		parsedA, err := Parse(a)
+		if err != nil {
+			return
+		}
+
+		if parsedA.String() != a {
+			t.Errorf("expected %q to round-trip, got %q", a, parsedA.String())
+		}
+
+		if !xsemver.IsValid(a) {
+			t.Errorf("expected %q to be valid for golang.org/x/mod/semver", a)
+		}
+
+		parsedB, err := Parse(b)
+		if err != nil {
+			return
+		}
+
+		if actual, expected := Compare(parsedA, parsedB), xsemver.Compare(a, b); actual != expected {
+			t.Errorf("expected Compare(%q, %q) to be %d, got %d", a, b, expected, actual)
+		}
+	})
+}